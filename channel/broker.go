@@ -0,0 +1,325 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/timoni-io/go-utils/metrics"
+)
+
+var ErrClosed = errors.New("channel is closed")
+
+// Policy decides what happens when subscriber buffer is full
+type Policy uint8
+
+const (
+	// publisher waits until subscriber receives the message
+	Block Policy = iota
+	// new message is dropped
+	DropNewest
+	// the oldest buffered message is dropped to make room for the new one
+	DropOldest
+)
+
+type SubscribeOptions struct {
+	Buffer int
+	Policy Policy
+	// deliver last value of every matching topic on subscribe,
+	// retained messages are delivered only if they fit in the buffer
+	Retained bool
+}
+
+type Message[T any] struct {
+	Topic string
+	Value T
+}
+
+type TopicMetrics struct {
+	Published metrics.Value
+	Delivered metrics.Value
+	Dropped   metrics.Value
+}
+
+type Subscription[T any] struct {
+	C <-chan Message[T]
+
+	ch      chan Message[T]
+	pattern []string
+	policy  Policy
+	broker  *Broker[T]
+	client  Client[Message[T]]
+	done    chan struct{}
+	once    sync.Once
+	// read lock keeps ch open while delivering
+	lock sync.RWMutex
+}
+
+// Unsubscribe removes subscription and closes its channel
+func (s *Subscription[T]) Unsubscribe() {
+	s.broker.hub.unregister(s.client)
+	s.close()
+}
+
+func (s *Subscription[T]) close() {
+	s.once.Do(func() {
+		// unblock publishers waiting on this subscriber before waiting for them
+		close(s.done)
+
+		s.broker.lock.Lock()
+		delete(s.broker.subs, s.client)
+		s.broker.lock.Unlock()
+
+		s.lock.Lock()
+		close(s.ch)
+		s.lock.Unlock()
+	})
+}
+
+// Broker delivers messages published on topics to matching subscribers.
+// Topics are dot separated, patterns may use "*" for single segment and
+// ">" as the last segment for one or more segments, e.g. "orders.*", "orders.>".
+// Subscriptions are clients of the underlying Hub, which closes them with the broker.
+type Broker[T any] struct {
+	hub  *Hub[Message[T]]
+	lock sync.RWMutex
+	subs map[Client[Message[T]]]*Subscription[T]
+
+	// guards retained and metrics
+	mu       sync.Mutex
+	retained map[string]T
+	metrics  map[string]*TopicMetrics
+}
+
+// NewBroker creates broker which is closed when ctx is done
+func NewBroker[T any](ctx context.Context) *Broker[T] {
+	b := &Broker[T]{
+		subs:     map[Client[Message[T]]]*Subscription[T]{},
+		retained: map[string]T{},
+		metrics:  map[string]*TopicMetrics{},
+	}
+	b.hub = NewHub[Message[T]](ctx, 0).OnDisconnect(b.disconnect)
+	return b
+}
+
+// disconnect closes subscription of client unregistered from hub
+func (b *Broker[T]) disconnect(client Client[Message[T]]) {
+	b.lock.RLock()
+	s, ok := b.subs[client]
+	b.lock.RUnlock()
+
+	if ok {
+		s.close()
+	}
+}
+
+// Close closes all subscriptions, Publish returns ErrClosed afterwards.
+// Publishers blocked on subscribers are released.
+func (b *Broker[T]) Close() {
+	b.hub.Close()
+}
+
+// match checks topic against pattern segments
+func match(pattern []string, topic []string) bool {
+	for i, p := range pattern {
+		if p == ">" {
+			return i == len(pattern)-1 && len(topic) > i
+		}
+		if i >= len(topic) || (p != "*" && p != topic[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(topic)
+}
+
+// Subscribe returns subscription for topics matching pattern, unsubscribed when ctx is done
+func (b *Broker[T]) Subscribe(ctx context.Context, pattern string, opts SubscribeOptions) (*Subscription[T], error) {
+	ch := make(chan Message[T], opts.Buffer)
+	s := &Subscription[T]{
+		C:       ch,
+		ch:      ch,
+		pattern: strings.Split(pattern, "."),
+		policy:  opts.Policy,
+		broker:  b,
+		done:    make(chan struct{}),
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	// hub client is never written to, it only tracks subscription lifecycle
+	s.client = b.hub.Register(ctx)
+	select {
+	case <-s.client:
+		// registered after close
+		return nil, ErrClosed
+	default:
+	}
+	b.subs[s.client] = s
+
+	if opts.Retained {
+		b.mu.Lock()
+		for topic, v := range b.retained {
+			if !match(s.pattern, strings.Split(topic, ".")) {
+				continue
+			}
+			select {
+			case ch <- Message[T]{Topic: topic, Value: v}:
+			default:
+			}
+		}
+		b.mu.Unlock()
+	}
+
+	return s, nil
+}
+
+// topicMetrics returns metrics for topic
+func (b *Broker[T]) topicMetrics(topic string) *TopicMetrics {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	m, ok := b.metrics[topic]
+	if !ok {
+		m = &TopicMetrics{}
+		b.metrics[topic] = m
+	}
+	return m
+}
+
+// Publish sends value to all subscribers matching topic and retains it as the topic last value.
+// With Block policy it waits for every matching subscriber.
+func (b *Broker[T]) Publish(topic string, v T) error {
+	if b.hub.closing() {
+		return ErrClosed
+	}
+
+	segments := strings.Split(topic, ".")
+	msg := Message[T]{Topic: topic, Value: v}
+
+	b.mu.Lock()
+	b.retained[topic] = v
+	b.mu.Unlock()
+
+	m := b.topicMetrics(topic)
+	m.Published.Add(1)
+
+	b.lock.RLock()
+	subs := make([]*Subscription[T], 0, len(b.subs))
+	for _, s := range b.subs {
+		if match(s.pattern, segments) {
+			subs = append(subs, s)
+		}
+	}
+	b.lock.RUnlock()
+
+	for _, s := range subs {
+		if b.deliver(s, msg, m) {
+			m.Delivered.Add(1)
+		} else {
+			m.Dropped.Add(1)
+		}
+	}
+
+	return nil
+}
+
+func (b *Broker[T]) deliver(s *Subscription[T], msg Message[T], m *TopicMetrics) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+
+	policy := s.policy
+	if policy == DropOldest && cap(s.ch) == 0 {
+		// nothing to drop from unbuffered channel
+		policy = DropNewest
+	}
+
+	switch policy {
+	case DropNewest:
+		select {
+		case s.ch <- msg:
+			return true
+		default:
+			return false
+		}
+
+	case DropOldest:
+		for {
+			select {
+			case s.ch <- msg:
+				return true
+			default:
+			}
+
+			select {
+			case <-s.ch:
+				m.Dropped.Add(1)
+			default:
+			}
+		}
+
+	default:
+		select {
+		case s.ch <- msg:
+			return true
+		case <-s.done:
+			return false
+		case <-b.hub.done:
+			return false
+		case <-b.hub.ctx.Done():
+			return false
+		}
+	}
+}
+
+// Retained returns last value published on topic
+func (b *Broker[T]) Retained(topic string) (v T, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	v, ok = b.retained[topic]
+	return
+}
+
+// ClearRetained removes last value of topic
+func (b *Broker[T]) ClearRetained(topic string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.retained, topic)
+}
+
+// Metrics returns metrics of topic, nil if nothing was published on it
+func (b *Broker[T]) Metrics(topic string) *TopicMetrics {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.metrics[topic]
+}
+
+// Topics returns all topics on which something was published
+func (b *Broker[T]) Topics() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	topics := make([]string, 0, len(b.metrics))
+	for topic := range b.metrics {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// Subscribers returns number of active subscriptions
+func (b *Broker[T]) Subscribers() int {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return len(b.subs)
+}
@@ -0,0 +1,145 @@
+package channel
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func receive[T any](t *testing.T, ch <-chan Message[T]) Message[T] {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("message not received")
+	}
+	return Message[T]{}
+}
+
+func TestBrokerWildcard(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := NewBroker[int](ctx)
+
+	one, _ := b.Subscribe(ctx, "orders.*", SubscribeOptions{Buffer: 10})
+	all, _ := b.Subscribe(ctx, "orders.>", SubscribeOptions{Buffer: 10})
+
+	b.Publish("orders.new", 1)
+	b.Publish("orders.eu.new", 2)
+	b.Publish("users.new", 3)
+
+	if msg := receive(t, one.C); msg.Topic != "orders.new" || msg.Value != 1 {
+		t.Errorf("invalid message %v", msg)
+	}
+	if msg := receive(t, all.C); msg.Value != 1 {
+		t.Errorf("invalid message %v", msg)
+	}
+	if msg := receive(t, all.C); msg.Value != 2 {
+		t.Errorf("invalid message %v", msg)
+	}
+	if len(one.C) != 0 || len(all.C) != 0 {
+		t.Error("unexpected messages")
+	}
+
+	if m := b.Metrics("orders.new"); m.Published.Load() != 1 || m.Delivered.Load() != 2 {
+		t.Errorf("invalid metrics %v %v", m.Published.String(), m.Delivered.String())
+	}
+}
+
+func TestBrokerRetained(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := NewBroker[string](ctx)
+	b.Publish("config", "v1")
+	b.Publish("config", "v2")
+
+	sub, _ := b.Subscribe(ctx, "config", SubscribeOptions{Buffer: 1, Retained: true})
+	if msg := receive(t, sub.C); msg.Value != "v2" {
+		t.Errorf("invalid retained value %v", msg)
+	}
+}
+
+func TestBrokerDropOldest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := NewBroker[int](ctx)
+	sub, _ := b.Subscribe(ctx, "x", SubscribeOptions{Buffer: 2, Policy: DropOldest})
+
+	for i := 0; i < 5; i++ {
+		b.Publish("x", i)
+	}
+
+	if receive(t, sub.C).Value != 3 || receive(t, sub.C).Value != 4 {
+		t.Error("oldest messages not dropped")
+	}
+	if b.Metrics("x").Dropped.Load() != 3 {
+		t.Errorf("invalid dropped %s", b.Metrics("x").Dropped.String())
+	}
+}
+
+func TestBrokerUnsubscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	b := NewBroker[int](ctx)
+	subCtx, subCancel := context.WithCancel(ctx)
+	sub, _ := b.Subscribe(subCtx, "x", SubscribeOptions{})
+
+	// blocked publisher is released by unsubscribe
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		subCancel()
+	}()
+	b.Publish("x", 1)
+
+	if _, ok := <-sub.C; ok {
+		t.Error("channel not closed")
+	}
+
+	other, _ := b.Subscribe(context.Background(), "x", SubscribeOptions{})
+	cancel()
+	if _, ok := <-other.C; ok {
+		t.Error("channel not closed after broker ctx done")
+	}
+	if err := b.Publish("x", 1); err != ErrClosed {
+		t.Errorf("invalid err %v", err)
+	}
+}
+
+func TestBrokerCloseStalled(t *testing.T) {
+	b := NewBroker[int](context.Background())
+	sub, _ := b.Subscribe(context.Background(), "x", SubscribeOptions{Policy: Block})
+
+	published := make(chan error)
+	go func() {
+		published <- b.Publish("x", 1)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		b.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked by stalled subscriber")
+	}
+	if err := <-published; err != nil {
+		t.Errorf("invalid err %v", err)
+	}
+	if _, ok := <-sub.C; ok {
+		t.Error("channel not closed")
+	}
+	if b.Subscribers() != 0 {
+		t.Errorf("subscribers left %d", b.Subscribers())
+	}
+	if _, err := b.Subscribe(context.Background(), "x", SubscribeOptions{}); err != ErrClosed {
+		t.Errorf("invalid err %v", err)
+	}
+}
@@ -0,0 +1,393 @@
+package channel
+
+import (
+	"context"
+	"hash/fnv"
+	"reflect"
+	"time"
+)
+
+// All combinators stop when ctx is done and close their outputs
+// when inputs are closed, so no goroutine outlives them.
+
+// send sends v to out, returns false if ctx is done
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// stopTimer stops timer and drains its channel, so it can be safely reset
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
+
+// OrDone forwards values from in until in is closed or ctx is done
+func OrDone[T any](ctx context.Context, in <-chan T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok || !send(ctx, out, v) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Tee copies every value to n outputs, waits until all outputs receive the value
+func Tee[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	if n < 1 {
+		n = 1
+	}
+
+	outs := make([]chan T, n)
+	for i := range outs {
+		outs[i] = make(chan T)
+	}
+
+	go func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+
+		for v := range OrDone(ctx, in) {
+			for _, out := range outs {
+				if !send(ctx, out, v) {
+					return
+				}
+			}
+		}
+	}()
+
+	return readOnly(outs)
+}
+
+// FanOut distributes values over n outputs in round-robin order
+func FanOut[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	next := 0
+	return fanOut(ctx, in, n, func(v T) int {
+		i := next
+		next++
+		return i
+	})
+}
+
+// FanOutByKey distributes values over n outputs, values with the same key go to the same output
+func FanOutByKey[T any](ctx context.Context, in <-chan T, n int, key func(v T) string) []<-chan T {
+	return fanOut(ctx, in, n, func(v T) int {
+		h := fnv.New32a()
+		h.Write([]byte(key(v)))
+		return int(h.Sum32() % uint32(n))
+	})
+}
+
+func fanOut[T any](ctx context.Context, in <-chan T, n int, route func(v T) int) []<-chan T {
+	if n < 1 {
+		n = 1
+	}
+
+	outs := make([]chan T, n)
+	for i := range outs {
+		outs[i] = make(chan T)
+	}
+
+	go func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+
+		for v := range OrDone(ctx, in) {
+			if !send(ctx, outs[route(v)%n], v) {
+				return
+			}
+		}
+	}()
+
+	return readOnly(outs)
+}
+
+func readOnly[T any](chans []chan T) []<-chan T {
+	out := make([]<-chan T, len(chans))
+	for i, c := range chans {
+		out[i] = c
+	}
+	return out
+}
+
+// Batch groups values into slices of up to size values,
+// batch is emitted when full or maxWait after its first value
+func Batch[T any](ctx context.Context, in <-chan T, size int, maxWait time.Duration) <-chan []T {
+	out := make(chan []T)
+	go func() {
+		defer close(out)
+
+		timer := time.NewTimer(maxWait)
+		timer.Stop()
+		defer timer.Stop()
+
+		var batch []T
+		flush := func() bool {
+			stopTimer(timer)
+			if len(batch) == 0 {
+				return true
+			}
+			b := batch
+			batch = nil
+			return send(ctx, out, b)
+		}
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+
+				if len(batch) == 0 {
+					timer.Reset(maxWait)
+				}
+				batch = append(batch, v)
+				if len(batch) >= size && !flush() {
+					return
+				}
+
+			case <-timer.C:
+				if !flush() {
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Debounce emits the last value after no new value came for d,
+// pending value is emitted when in is closed
+func Debounce[T any](ctx context.Context, in <-chan T, d time.Duration) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+
+		timer := time.NewTimer(d)
+		timer.Stop()
+		defer timer.Stop()
+
+		var last T
+		pending := false
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					if pending {
+						send(ctx, out, last)
+					}
+					return
+				}
+
+				last, pending = v, true
+				stopTimer(timer)
+				timer.Reset(d)
+
+			case <-timer.C:
+				pending = false
+				if !send(ctx, out, last) {
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Throttle passes at most one value per interval, values are delayed, not dropped
+func Throttle[T any](ctx context.Context, in <-chan T, interval time.Duration) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+
+		timer := time.NewTimer(interval)
+		stopTimer(timer)
+		defer timer.Stop()
+
+		var last time.Time
+		for v := range OrDone(ctx, in) {
+			if wait := interval - time.Since(last); wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if !send(ctx, out, v) {
+				return
+			}
+			last = time.Now()
+		}
+	}()
+	return out
+}
+
+// Buffer forwards values with unbounded buffer, in is never blocked by slow consumer
+func Buffer[T any](ctx context.Context, in <-chan T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+
+		var queue []T
+		for in != nil || len(queue) > 0 {
+			// nil channel blocks, so send is enabled only with queued values
+			var sendCh chan T
+			var next T
+			if len(queue) > 0 {
+				sendCh = out
+				next = queue[0]
+			}
+
+			select {
+			case v, ok := <-in:
+				if !ok {
+					in = nil
+					continue
+				}
+				queue = append(queue, v)
+
+			case sendCh <- next:
+				var zero T
+				queue[0] = zero
+				queue = queue[1:]
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// MergePriority merges channels, when multiple values are ready
+// the value from the channel with lower index is forwarded first
+func MergePriority[T any](ctx context.Context, ins ...<-chan T) <-chan T {
+	// copy, closed channels are set to nil
+	ins = append([]<-chan T(nil), ins...)
+
+	out := make(chan T)
+	go func() {
+		defer close(out)
+
+		open := len(ins)
+		cases := make([]reflect.SelectCase, len(ins)+1)
+		for i, in := range ins {
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(in)}
+		}
+		cases[len(ins)] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+
+		for open > 0 {
+			i, v, ok := prioritySelect(ins, cases)
+			if i == len(ins) {
+				return
+			}
+			if !ok {
+				// closed channel, disable its case
+				ins[i] = nil
+				cases[i].Chan = reflect.ValueOf((<-chan T)(nil))
+				open--
+				continue
+			}
+
+			if !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// prioritySelect receives from the first ready channel, blocks on all if none is ready
+func prioritySelect[T any](ins []<-chan T, cases []reflect.SelectCase) (int, T, bool) {
+	for i, in := range ins {
+		if in == nil {
+			continue
+		}
+		select {
+		case v, ok := <-in:
+			return i, v, ok
+		default:
+		}
+	}
+
+	i, v, ok := reflect.Select(cases)
+	if i == len(ins) || !ok {
+		return i, *new(T), false
+	}
+	x, _ := v.Interface().(T)
+	return i, x, true
+}
+
+// Stage is a single pipeline step
+type Stage[T any] func(ctx context.Context, in <-chan T) <-chan T
+
+// Pipeline connects stages, output of every stage is input of the next one
+func Pipeline[T any](ctx context.Context, in <-chan T, stages ...Stage[T]) <-chan T {
+	out := in
+	for _, stage := range stages {
+		out = stage(ctx, out)
+	}
+	return OrDone(ctx, out)
+}
+
+// Map returns channel with fn applied to every value
+func Map[T any, O any](ctx context.Context, in <-chan T, fn func(v T) O) <-chan O {
+	out := make(chan O)
+	go func() {
+		defer close(out)
+		for v := range OrDone(ctx, in) {
+			if !send(ctx, out, fn(v)) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Filter returns channel with values for which fn returns true
+func Filter[T any](ctx context.Context, in <-chan T, fn func(v T) bool) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for v := range OrDone(ctx, in) {
+			if fn(v) && !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,186 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+)
+
+func source[T any](values ...T) <-chan T {
+	ch := make(chan T, len(values))
+	for _, v := range values {
+		ch <- v
+	}
+	close(ch)
+	return ch
+}
+
+func collect[T any](ch <-chan T) []T {
+	out := []T{}
+	for v := range ch {
+		out = append(out, v)
+	}
+	return out
+}
+
+func TestTee(t *testing.T) {
+	ctx := context.Background()
+	outs := Tee(ctx, source(1, 2, 3), 2)
+
+	done := make(chan []int)
+	go func() { done <- collect(outs[1]) }()
+
+	if fmt.Sprint(collect(outs[0])) != "[1 2 3]" || fmt.Sprint(<-done) != "[1 2 3]" {
+		t.Fail()
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	ctx := context.Background()
+
+	outs := FanOut(ctx, source(1, 2, 3, 4), 2)
+	results := make(chan []int, 2)
+	for _, out := range outs {
+		go func(out <-chan int) { results <- collect(out) }(out)
+	}
+
+	a, b := <-results, <-results
+	if len(a) != 2 || len(b) != 2 {
+		t.Errorf("values not distributed %v %v", a, b)
+	}
+
+	keyed := FanOutByKey(ctx, source("a1", "b1", "a2", "b2"), 2, func(v string) string { return v[:1] })
+	keyedResults := make(chan []string, 2)
+	for _, out := range keyed {
+		go func(out <-chan string) { keyedResults <- collect(out) }(out)
+	}
+	for i := 0; i < 2; i++ {
+		values := <-keyedResults
+		for _, v := range values {
+			if v[:1] != values[0][:1] {
+				t.Errorf("keys mixed %v", values)
+			}
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+
+	in := make(chan int)
+	out := Batch(ctx, in, 2, 20*time.Millisecond)
+
+	go func() {
+		in <- 1
+		in <- 2
+		in <- 3
+		time.Sleep(50 * time.Millisecond)
+		in <- 4
+		close(in)
+	}()
+
+	if got := fmt.Sprint(collect(out)); got != "[[1 2] [3] [4]]" {
+		t.Errorf("invalid batches %s", got)
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	ctx := context.Background()
+
+	in := make(chan int)
+	out := Debounce(ctx, in, 20*time.Millisecond)
+
+	go func() {
+		in <- 1
+		in <- 2
+		time.Sleep(50 * time.Millisecond)
+		in <- 3
+		close(in)
+	}()
+
+	if got := fmt.Sprint(collect(out)); got != "[2 3]" {
+		t.Errorf("invalid values %s", got)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	ctx := context.Background()
+
+	start := time.Now()
+	out := collect(Throttle(ctx, source(1, 2, 3), 10*time.Millisecond))
+
+	if len(out) != 3 {
+		t.Errorf("values dropped %v", out)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Error("values not throttled")
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	ctx := context.Background()
+
+	in := make(chan int)
+	out := Buffer(ctx, in)
+
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+	close(in)
+
+	if got := collect(out); len(got) != 100 || got[99] != 99 {
+		t.Errorf("invalid values %v", got)
+	}
+}
+
+func TestMergePriority(t *testing.T) {
+	ctx := context.Background()
+
+	got := collect(MergePriority(ctx, source(1, 2), source(3, 4)))
+	if fmt.Sprint(got) != "[1 2 3 4]" {
+		t.Errorf("invalid order %v", got)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	ctx := context.Background()
+
+	double := func(ctx context.Context, in <-chan int) <-chan int {
+		return Map(ctx, in, func(v int) int { return v * 2 })
+	}
+	even := func(ctx context.Context, in <-chan int) <-chan int {
+		return Filter(ctx, in, func(v int) bool { return v%4 == 0 })
+	}
+
+	got := collect(Pipeline(ctx, source(1, 2, 3, 4), double, even))
+	sort.Ints(got)
+	if fmt.Sprint(got) != "[4 8]" {
+		t.Errorf("invalid values %v", got)
+	}
+}
+
+func TestCombinatorsCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	in := make(chan int)
+	outs := []<-chan int{
+		OrDone(ctx, in),
+		Tee(ctx, in, 2)[0],
+		FanOut(ctx, in, 2)[0],
+		Debounce(ctx, in, time.Second),
+		Throttle(ctx, in, time.Second),
+		Buffer(ctx, in),
+		MergePriority(ctx, in),
+	}
+
+	cancel()
+	for _, out := range outs {
+		select {
+		case <-out:
+		case <-time.After(time.Second):
+			t.Fatal("output not closed after cancel")
+		}
+	}
+}
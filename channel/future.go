@@ -0,0 +1,220 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/timoni-io/go-utils"
+)
+
+var ErrNoFutures = errors.New("no futures")
+
+// Future is a result which will be available later.
+// It must be created by Promise, Async, Resolved or Rejected, zero value Future never completes.
+type Future[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// Promise completes its future, only the first completion counts
+type Promise[T any] struct {
+	f    *Future[T]
+	once sync.Once
+}
+
+func NewPromise[T any]() *Promise[T] {
+	return &Promise[T]{f: &Future[T]{done: make(chan struct{})}}
+}
+
+// Future returns future completed by promise
+func (p *Promise[T]) Future() *Future[T] {
+	return p.f
+}
+
+// Complete sets result of the future, returns false if it was already completed
+func (p *Promise[T]) Complete(v T, err error) bool {
+	ok := false
+	p.once.Do(func() {
+		p.f.val, p.f.err = v, err
+		close(p.f.done)
+		ok = true
+	})
+	return ok
+}
+
+func (p *Promise[T]) Resolve(v T) bool {
+	return p.Complete(v, nil)
+}
+
+func (p *Promise[T]) Reject(err error) bool {
+	var zero T
+	return p.Complete(zero, err)
+}
+
+// Async runs fn in new goroutine, panic is returned as error
+func Async[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) *Future[T] {
+	p := NewPromise[T]()
+	go func() {
+		p.Complete(protect(ctx, fn))
+	}()
+	return p.Future()
+}
+
+// protect calls fn converting panic to error
+func protect[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) (v T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = utils.CapturePanic(r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// Resolved returns completed future with value
+func Resolved[T any](v T) *Future[T] {
+	p := NewPromise[T]()
+	p.Resolve(v)
+	return p.Future()
+}
+
+// Rejected returns completed future with error
+func Rejected[T any](err error) *Future[T] {
+	p := NewPromise[T]()
+	p.Reject(err)
+	return p.Future()
+}
+
+// Done returns channel closed when future is completed
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Await waits for result, returns ctx error if ctx is done first
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// AwaitTimeout waits for result at most timeout, returns utils.ErrTimeout then
+func (f *Future[T]) AwaitTimeout(timeout time.Duration) (T, error) {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-t.C:
+		var zero T
+		return zero, utils.ErrTimeout
+	}
+}
+
+// Result returns result without waiting, ok is false if future is not completed
+func (f *Future[T]) Result() (v T, err error, ok bool) {
+	select {
+	case <-f.done:
+		return f.val, f.err, true
+	default:
+		return v, nil, false
+	}
+}
+
+// Then returns future with fn applied to the result, error is passed without calling fn
+func Then[T any, O any](f *Future[T], fn func(v T) (O, error)) *Future[O] {
+	p := NewPromise[O]()
+	go func() {
+		<-f.done
+		if f.err != nil {
+			p.Reject(f.err)
+			return
+		}
+		p.Complete(protect(context.Background(), func(context.Context) (O, error) {
+			return fn(f.val)
+		}))
+	}()
+	return p.Future()
+}
+
+// watch calls fn with index of every future when it completes,
+// until ctx is done or the result future is completed
+func watch[T any, O any](ctx context.Context, p *Promise[O], fs []*Future[T], fn func(i int)) {
+	for i, f := range fs {
+		go func(i int, f *Future[T]) {
+			select {
+			case <-f.done:
+				fn(i)
+			case <-p.f.done:
+			case <-ctx.Done():
+				p.Reject(ctx.Err())
+			}
+		}(i, f)
+	}
+}
+
+// All returns future with values of all futures in order, it fails on the first error
+func All[T any](ctx context.Context, fs ...*Future[T]) *Future[[]T] {
+	p := NewPromise[[]T]()
+	out := make([]T, len(fs))
+	if len(fs) == 0 {
+		p.Resolve(out)
+		return p.Future()
+	}
+
+	left := int32(len(fs))
+	watch(ctx, p, fs, func(i int) {
+		if fs[i].err != nil {
+			p.Reject(fs[i].err)
+			return
+		}
+		out[i] = fs[i].val
+		if atomic.AddInt32(&left, -1) == 0 {
+			p.Resolve(out)
+		}
+	})
+	return p.Future()
+}
+
+// Any returns future with the first successful value,
+// if all futures fail it fails with error of the first one
+func Any[T any](ctx context.Context, fs ...*Future[T]) *Future[T] {
+	p := NewPromise[T]()
+	if len(fs) == 0 {
+		p.Reject(ErrNoFutures)
+		return p.Future()
+	}
+
+	left := int32(len(fs))
+	watch(ctx, p, fs, func(i int) {
+		if fs[i].err == nil {
+			p.Resolve(fs[i].val)
+			return
+		}
+		if atomic.AddInt32(&left, -1) == 0 {
+			p.Reject(fs[0].err)
+		}
+	})
+	return p.Future()
+}
+
+// Race returns future with result of the first completed future
+func Race[T any](ctx context.Context, fs ...*Future[T]) *Future[T] {
+	p := NewPromise[T]()
+	if len(fs) == 0 {
+		p.Reject(ErrNoFutures)
+		return p.Future()
+	}
+
+	watch(ctx, p, fs, func(i int) {
+		p.Complete(fs[i].val, fs[i].err)
+	})
+	return p.Future()
+}
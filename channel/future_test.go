@@ -0,0 +1,115 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/timoni-io/go-utils"
+)
+
+func delayed[T any](v T, err error, d time.Duration) *Future[T] {
+	return Async(context.Background(), func(context.Context) (T, error) {
+		time.Sleep(d)
+		return v, err
+	})
+}
+
+func TestPromise(t *testing.T) {
+	p := NewPromise[int]()
+	if _, _, ok := p.Future().Result(); ok {
+		t.Fail()
+	}
+
+	if !p.Resolve(1) || p.Resolve(2) || p.Reject(errors.New("x")) {
+		t.Error("promise completed twice")
+	}
+	if v, err := p.Future().Await(context.Background()); v != 1 || err != nil {
+		t.Errorf("unexpected result %v %v", v, err)
+	}
+}
+
+func TestAwaitTimeout(t *testing.T) {
+	f := NewPromise[int]().Future()
+	if _, err := f.AwaitTimeout(10 * time.Millisecond); err != utils.ErrTimeout {
+		t.Errorf("expected ErrTimeout, got %v", err)
+	}
+}
+
+func TestAsyncPanic(t *testing.T) {
+	f := Async(context.Background(), func(context.Context) (int, error) {
+		panic("boom")
+	})
+	if _, err := f.Await(context.Background()); err == nil || err.Error() != "panic: boom" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestThen(t *testing.T) {
+	f := Then(Resolved(2), func(v int) (string, error) {
+		return strconv.Itoa(v * 2), nil
+	})
+	if v, err := f.Await(context.Background()); v != "4" || err != nil {
+		t.Errorf("unexpected result %v %v", v, err)
+	}
+
+	errX := errors.New("x")
+	f = Then(Rejected[int](errX), func(v int) (string, error) {
+		t.Error("fn called on error")
+		return "", nil
+	})
+	if _, err := f.Await(context.Background()); err != errX {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestAll(t *testing.T) {
+	ctx := context.Background()
+
+	v, err := All(ctx, delayed(1, nil, 20*time.Millisecond), Resolved(2)).Await(ctx)
+	if err != nil || fmt.Sprint(v) != "[1 2]" {
+		t.Errorf("unexpected result %v %v", v, err)
+	}
+
+	errX := errors.New("x")
+	if _, err := All(ctx, NewPromise[int]().Future(), Rejected[int](errX)).Await(ctx); err != errX {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestAny(t *testing.T) {
+	ctx := context.Background()
+	errX, errY := errors.New("x"), errors.New("y")
+
+	v, err := Any(ctx, Rejected[int](errX), delayed(2, nil, 10*time.Millisecond)).Await(ctx)
+	if v != 2 || err != nil {
+		t.Errorf("unexpected result %v %v", v, err)
+	}
+
+	if _, err := Any(ctx, Rejected[int](errX), delayed(0, errY, 10*time.Millisecond)).Await(ctx); err != errX {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if _, err := Any[int](ctx).Await(ctx); err != ErrNoFutures {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestRace(t *testing.T) {
+	ctx := context.Background()
+	errX := errors.New("x")
+
+	if _, err := Race(ctx, delayed(1, nil, 50*time.Millisecond), delayed(0, errX, time.Millisecond)).Await(ctx); err != errX {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	f := Race(cctx, NewPromise[int]().Future())
+	cancel()
+	if _, err := f.Await(ctx); err != context.Canceled {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -2,69 +2,251 @@ package channel
 
 import (
 	"context"
+	"sync"
 	"time"
 )
 
 type Client[T any] chan T
 
+// Hub broadcasts messages to all registered clients.
+// It is closed when ctx is done, Close or Shutdown is called, client channels are closed then.
 type Hub[T any] struct {
-	ctx        context.Context
-	clients    map[Client[T]]struct{}
-	broadcast  chan T
-	register   chan Client[T]
-	unregister chan Client[T]
+	ctx       context.Context
+	lock      sync.RWMutex
+	clients   map[Client[T]]struct{}
+	closed    bool
+	broadcast chan T
+
+	onConnect    func(c Client[T])
+	onDisconnect func(c Client[T])
+
+	// done stops accepting messages, abort stops delivering buffered ones
+	done      chan struct{}
+	abort     chan struct{}
+	stopped   chan struct{}
+	doneOnce  sync.Once
+	abortOnce sync.Once
 }
 
 func NewHub[T any](ctx context.Context, buffer int) *Hub[T] {
 	h := &Hub[T]{
-		ctx:        ctx,
-		clients:    make(map[Client[T]]struct{}),
-		broadcast:  make(chan T, buffer),
-		register:   make(chan Client[T]),
-		unregister: make(chan Client[T]),
+		ctx:       ctx,
+		clients:   make(map[Client[T]]struct{}),
+		broadcast: make(chan T, buffer),
+		done:      make(chan struct{}),
+		abort:     make(chan struct{}),
+		stopped:   make(chan struct{}),
 	}
 	go h.run()
 	return h
 }
 
+// OnConnect sets function called after client is registered
+func (h *Hub[T]) OnConnect(fn func(c Client[T])) *Hub[T] {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.onConnect = fn
+	return h
+}
+
+// OnDisconnect sets function called after client is unregistered and closed
+func (h *Hub[T]) OnDisconnect(fn func(c Client[T])) *Hub[T] {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.onDisconnect = fn
+	return h
+}
+
 func (h *Hub[T]) run() {
+	defer close(h.stopped)
+	defer h.closeClients()
+
 	for {
 		select {
 		case <-h.ctx.Done():
 			return
-		case client := <-h.register:
-			h.clients[client] = struct{}{}
-		case client := <-h.unregister:
-			delete(h.clients, client)
-			close(client)
+		case <-h.abort:
+			return
 		case message := <-h.broadcast:
-			t := time.NewTicker(100 * time.Millisecond)
-			for client := range h.clients {
-				//send with timeout
+			h.send(message)
+		case <-h.done:
+			// deliver buffered messages until aborted
+			for {
 				select {
-				case client <- message:
-					t.Reset(100 * time.Millisecond)
-				case <-t.C:
+				case message := <-h.broadcast:
+					h.send(message)
+				case <-h.abort:
+					return
+				default:
+					return
 				}
 			}
-			t.Stop()
 		}
 	}
 }
 
+// send delivers message to all clients, slow client is skipped after timeout
+func (h *Hub[T]) send(message T) {
+	// read lock keeps clients open while sending
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	t := time.NewTimer(100 * time.Millisecond)
+	defer t.Stop()
+
+	for client := range h.clients {
+		select {
+		case client <- message:
+		case <-t.C:
+		case <-h.abort:
+			return
+		case <-h.ctx.Done():
+			return
+		}
+		stopTimer(t)
+		t.Reset(100 * time.Millisecond)
+	}
+}
+
+func (h *Hub[T]) closeClients() {
+	h.lock.Lock()
+	h.closed = true
+	clients := h.clients
+	h.clients = map[Client[T]]struct{}{}
+	onDisconnect := h.onDisconnect
+	h.lock.Unlock()
+
+	for client := range clients {
+		close(client)
+		if onDisconnect != nil {
+			onDisconnect(client)
+		}
+	}
+}
+
+// Register returns client receiving broadcasted messages, client is closed when ctx is done.
+// Client registered after close or on nil hub is already closed.
 func (h *Hub[T]) Register(ctx context.Context) Client[T] {
 	client := make(Client[T])
-	h.register <- client
+	if h == nil {
+		close(client)
+		return client
+	}
+
+	h.lock.Lock()
+	if h.closed {
+		h.lock.Unlock()
+		close(client)
+		return client
+	}
+	h.clients[client] = struct{}{}
+	onConnect := h.onConnect
+	h.lock.Unlock()
+
+	if onConnect != nil {
+		onConnect(client)
+	}
 
 	go func() {
-		<-ctx.Done()
-		h.unregister <- client
+		select {
+		case <-ctx.Done():
+			h.unregister(client)
+		case <-h.stopped:
+		}
 	}()
 	return client
 }
 
-func (h *Hub[T]) Broadcast(data T) {
-	if len(h.clients) > 0 {
-		h.broadcast <- data
+func (h *Hub[T]) unregister(client Client[T]) {
+	h.lock.Lock()
+	if _, ok := h.clients[client]; !ok {
+		h.lock.Unlock()
+		return
+	}
+	delete(h.clients, client)
+	close(client)
+	onDisconnect := h.onDisconnect
+	h.lock.Unlock()
+
+	if onDisconnect != nil {
+		onDisconnect(client)
+	}
+}
+
+// Subscribers returns number of registered clients
+func (h *Hub[T]) Subscribers() int {
+	if h == nil {
+		return 0
+	}
+
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return len(h.clients)
+}
+
+// Broadcast queues message for all clients, message is dropped if there are no clients.
+// Returns ErrClosed after hub is closed.
+func (h *Hub[T]) Broadcast(data T) error {
+	if h == nil {
+		return ErrClosed
+	}
+
+	if h.closing() {
+		return ErrClosed
+	}
+
+	if h.Subscribers() == 0 {
+		return nil
+	}
+
+	select {
+	case h.broadcast <- data:
+		return nil
+	case <-h.done:
+		return ErrClosed
+	case <-h.stopped:
+		return ErrClosed
+	}
+}
+
+// closing checks if hub stopped accepting messages
+func (h *Hub[T]) closing() bool {
+	select {
+	case <-h.done:
+		return true
+	case <-h.stopped:
+		return true
+	default:
+	}
+	return h.ctx.Err() != nil
+}
+
+// Close closes hub and all clients, buffered messages are dropped
+func (h *Hub[T]) Close() {
+	if h == nil {
+		return
+	}
+
+	h.doneOnce.Do(func() { close(h.done) })
+	h.abortOnce.Do(func() { close(h.abort) })
+	<-h.stopped
+}
+
+// Shutdown stops accepting messages, delivers buffered ones and closes all clients.
+// When ctx is done first, remaining messages are dropped and ctx error is returned.
+func (h *Hub[T]) Shutdown(ctx context.Context) error {
+	if h == nil {
+		return nil
+	}
+
+	h.doneOnce.Do(func() { close(h.done) })
+
+	select {
+	case <-h.stopped:
+		return nil
+	case <-ctx.Done():
+		h.abortOnce.Do(func() { close(h.abort) })
+		<-h.stopped
+		return ctx.Err()
 	}
 }
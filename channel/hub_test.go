@@ -0,0 +1,125 @@
+package channel
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHubBroadcast(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := NewHub[int](ctx, 10)
+	a, b := h.Register(ctx), h.Register(ctx)
+	if h.Subscribers() != 2 {
+		t.Fatalf("expected 2 subscribers, got %d", h.Subscribers())
+	}
+
+	done := make(chan []int)
+	go func() { done <- collect(b) }()
+
+	for i := 0; i < 3; i++ {
+		if err := h.Broadcast(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		if v := <-a; v != i {
+			t.Errorf("expected %d, got %d", i, v)
+		}
+	}
+
+	h.Shutdown(context.Background())
+	if got := <-done; len(got) != 3 {
+		t.Errorf("unexpected values %v", got)
+	}
+	if _, ok := <-a; ok {
+		t.Error("client not closed")
+	}
+}
+
+func TestHubUnregister(t *testing.T) {
+	var connected, disconnected int32
+	h := NewHub[int](context.Background(), 0).
+		OnConnect(func(Client[int]) { atomic.AddInt32(&connected, 1) }).
+		OnDisconnect(func(Client[int]) { atomic.AddInt32(&disconnected, 1) })
+	defer h.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := h.Register(ctx)
+	cancel()
+
+	if _, ok := <-c; ok {
+		t.Error("client not closed")
+	}
+	if h.Subscribers() != 0 || atomic.LoadInt32(&connected) != 1 || atomic.LoadInt32(&disconnected) != 1 {
+		t.Fail()
+	}
+}
+
+func TestHubShutdown(t *testing.T) {
+	h := NewHub[int](context.Background(), 10)
+	c := h.Register(context.Background())
+
+	h.Broadcast(1)
+	h.Broadcast(2)
+
+	done := make(chan []int)
+	go func() { done <- collect(c) }()
+
+	if err := h.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-done; len(got) != 2 {
+		t.Errorf("buffered messages not delivered %v", got)
+	}
+
+	if err := h.Broadcast(3); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	if _, ok := <-h.Register(context.Background()); ok {
+		t.Error("client registered after close")
+	}
+}
+
+func TestHubShutdownTimeout(t *testing.T) {
+	h := NewHub[int](context.Background(), 10)
+	h.Register(context.Background())
+	for i := 0; i < 10; i++ {
+		h.Broadcast(i)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := h.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestHubContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := NewHub[int](ctx, 0)
+	c := h.Register(context.Background())
+	cancel()
+
+	if _, ok := <-c; ok {
+		t.Error("client not closed")
+	}
+	if err := h.Broadcast(1); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestHubNil(t *testing.T) {
+	var h *Hub[int]
+	h.Close()
+	if err := h.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if _, ok := <-h.Register(context.Background()); ok {
+		t.Error("client of nil hub not closed")
+	}
+}
@@ -1,6 +1,8 @@
 package channel
 
 import (
+	"context"
+	"reflect"
 	"sync"
 )
 
@@ -27,3 +29,154 @@ func Multi[T any](cs ...<-chan T) <-chan T {
 
 	return out
 }
+
+// Tagged is value with index of the source it came from
+type Tagged[T any] struct {
+	Index int
+	Value T
+}
+
+// MultiChan merges sources which can be added and removed at runtime
+type MultiChan[T any] struct {
+	C <-chan Tagged[T]
+
+	ctx     context.Context
+	out     chan Tagged[T]
+	lock    sync.Mutex
+	sources map[int]<-chan T
+	next    int
+
+	update chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// MultiContext merges sources tagging every value with source index (position in cs,
+// then indexes returned by Add). Ready sources are drained in random order, so busy
+// source can't starve others. Output is closed when ctx is done, Close is called or
+// the last source is closed.
+func MultiContext[T any](ctx context.Context, cs ...<-chan T) *MultiChan[T] {
+	out := make(chan Tagged[T])
+	m := &MultiChan[T]{
+		C:       out,
+		ctx:     ctx,
+		out:     out,
+		sources: make(map[int]<-chan T, len(cs)),
+		update:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	for _, c := range cs {
+		m.sources[m.next] = c
+		m.next++
+	}
+
+	go m.run()
+	return m
+}
+
+func (m *MultiChan[T]) signal() {
+	select {
+	case m.update <- struct{}{}:
+	default:
+	}
+}
+
+// Add adds source and returns its index
+func (m *MultiChan[T]) Add(c <-chan T) int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	idx := m.next
+	m.sources[idx] = c
+	m.next++
+
+	m.signal()
+	return idx
+}
+
+// Remove removes source without reading its remaining values, returns false if there is no such source
+func (m *MultiChan[T]) Remove(idx int) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.sources[idx]; !ok {
+		return false
+	}
+	delete(m.sources, idx)
+
+	m.signal()
+	return true
+}
+
+// Len returns number of sources
+func (m *MultiChan[T]) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return len(m.sources)
+}
+
+// Close stops forwarding and closes output
+func (m *MultiChan[T]) Close() {
+	m.once.Do(func() { close(m.done) })
+}
+
+// cases builds select cases, first three are ctx, done and update
+func (m *MultiChan[T]) cases() ([]reflect.SelectCase, []int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	cases := make([]reflect.SelectCase, 3, len(m.sources)+3)
+	cases[0] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(m.ctx.Done())}
+	cases[1] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(m.done)}
+	cases[2] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(m.update)}
+	indexes := make([]int, 3, len(m.sources)+3)
+
+	for idx, c := range m.sources {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)})
+		indexes = append(indexes, idx)
+	}
+
+	return cases, indexes
+}
+
+func (m *MultiChan[T]) run() {
+	defer close(m.out)
+
+	cases, indexes := m.cases()
+	for {
+		chosen, v, ok := reflect.Select(cases)
+		switch chosen {
+		case 0, 1:
+			return
+		case 2:
+			cases, indexes = m.cases()
+			continue
+		}
+
+		idx := indexes[chosen]
+		if !ok {
+			// source closed
+			m.lock.Lock()
+			delete(m.sources, idx)
+			last := len(m.sources) == 0
+			m.lock.Unlock()
+
+			if last {
+				return
+			}
+			cases, indexes = m.cases()
+			continue
+		}
+
+		x, _ := v.Interface().(T)
+		select {
+		case m.out <- Tagged[T]{Index: idx, Value: x}:
+		case <-m.ctx.Done():
+			return
+		case <-m.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package channel
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMultiContext(t *testing.T) {
+	m := MultiContext(context.Background(), source(1, 2), source(10, 20, 30))
+
+	got := map[int][]int{}
+	for v := range m.C {
+		got[v.Index] = append(got[v.Index], v.Value)
+	}
+
+	if len(got[0]) != 2 || len(got[1]) != 3 || got[1][2] != 30 {
+		t.Errorf("unexpected values %v", got)
+	}
+}
+
+func TestMultiContextAddRemove(t *testing.T) {
+	a := make(chan int)
+	m := MultiContext(context.Background(), (<-chan int)(a))
+	defer m.Close()
+
+	b := make(chan int)
+	idx := m.Add(b)
+	if idx != 1 || m.Len() != 2 {
+		t.Fatalf("unexpected index %d", idx)
+	}
+
+	go func() { b <- 5 }()
+	if v := <-m.C; v.Index != 1 || v.Value != 5 {
+		t.Errorf("unexpected value %v", v)
+	}
+
+	if !m.Remove(idx) || m.Remove(idx) {
+		t.Fail()
+	}
+
+	go func() { a <- 7 }()
+	if v := <-m.C; v.Index != 0 || v.Value != 7 {
+		t.Errorf("unexpected value %v", v)
+	}
+}
+
+func TestMultiContextFair(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	busy := make(chan int)
+	go func() {
+		for {
+			select {
+			case busy <- 1:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	m := MultiContext(ctx, busy, source(2))
+	for i := 0; i < 1000; i++ {
+		if v := <-m.C; v.Index == 1 {
+			return
+		}
+	}
+	t.Error("busy channel starved the other one")
+}
+
+func TestMultiContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := MultiContext(ctx, make(chan int))
+	cancel()
+
+	select {
+	case _, ok := <-m.C:
+		if ok {
+			t.Fail()
+		}
+	case <-time.After(time.Second):
+		t.Error("output not closed after cancel")
+	}
+}
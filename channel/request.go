@@ -0,0 +1,103 @@
+package channel
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Handler handles single request, ctx is the caller context
+type Handler[Req any, Resp any] func(ctx context.Context, req Req) (Resp, error)
+
+type request[Req any, Resp any] struct {
+	ctx   context.Context
+	req   Req
+	reply *Promise[Resp]
+}
+
+// Service handles requests with a pool of handler goroutines
+type Service[Req any, Resp any] struct {
+	ctx      context.Context
+	handler  Handler[Req, Resp]
+	requests chan request[Req, Resp]
+	done     chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewService starts workers handler goroutines (NumCPU if workers <= 0),
+// service is closed when ctx is done
+func NewService[Req any, Resp any](ctx context.Context, workers int, handler Handler[Req, Resp]) *Service[Req, Resp] {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	s := &Service[Req, Resp]{
+		ctx:      ctx,
+		handler:  handler,
+		requests: make(chan request[Req, Resp]),
+		done:     make(chan struct{}),
+	}
+
+	s.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go s.worker()
+	}
+	return s
+}
+
+func (s *Service[Req, Resp]) worker() {
+	defer s.wg.Done()
+	for {
+		select {
+		case r := <-s.requests:
+			if err := r.ctx.Err(); err != nil {
+				r.reply.Reject(err)
+				continue
+			}
+			r.reply.Complete(protect(r.ctx, func(ctx context.Context) (Resp, error) {
+				return s.handler(ctx, r.req)
+			}))
+		case <-s.done:
+			return
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// Go sends request and returns future with the response.
+// Future fails with ErrClosed if service is closed and with ctx error if ctx is done
+// before any handler takes the request.
+func (s *Service[Req, Resp]) Go(ctx context.Context, req Req) *Future[Resp] {
+	p := NewPromise[Resp]()
+	select {
+	case s.requests <- request[Req, Resp]{ctx: ctx, req: req, reply: p}:
+	case <-ctx.Done():
+		p.Reject(ctx.Err())
+	case <-s.done:
+		p.Reject(ErrClosed)
+	case <-s.ctx.Done():
+		p.Reject(ErrClosed)
+	}
+	return p.Future()
+}
+
+// Call sends request and waits for the response
+func (s *Service[Req, Resp]) Call(ctx context.Context, req Req) (Resp, error) {
+	return s.Go(ctx, req).Await(ctx)
+}
+
+// CallTimeout sends request and waits for the response at most timeout
+func (s *Service[Req, Resp]) CallTimeout(req Req, timeout time.Duration) (Resp, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return s.Call(ctx, req)
+}
+
+// Close stops accepting requests and waits for running handlers
+func (s *Service[Req, Resp]) Close() {
+	s.once.Do(func() { close(s.done) })
+	s.wg.Wait()
+}
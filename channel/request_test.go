@@ -0,0 +1,67 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServiceCall(t *testing.T) {
+	var running, peak int32
+	s := NewService(context.Background(), 2, func(ctx context.Context, req int) (int, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		if req < 0 {
+			return 0, errors.New("negative")
+		}
+		return req * 2, nil
+	})
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if v, err := s.Call(context.Background(), i); v != i*2 || err != nil {
+				t.Errorf("unexpected result %v %v", v, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Errorf("%d handlers running at once", peak)
+	}
+
+	if _, err := s.Call(context.Background(), -1); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestServiceTimeout(t *testing.T) {
+	s := NewService(context.Background(), 1, func(ctx context.Context, req int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+
+	if _, err := s.CallTimeout(1, 10*time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	s.Close()
+	if _, err := s.Call(context.Background(), 1); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+// Package errors provides errors with codes mapped to HTTP statuses, key/value context and aggregation.
+// Is, As and Unwrap are forwarded from the standard errors package, New takes a code unlike errors.New.
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type Code string
+
+const (
+	Unknown            Code = "unknown"
+	Internal           Code = "internal"
+	InvalidArgument    Code = "invalid_argument"
+	Unauthenticated    Code = "unauthenticated"
+	PermissionDenied   Code = "permission_denied"
+	NotFound           Code = "not_found"
+	AlreadyExists      Code = "already_exists"
+	Conflict           Code = "conflict"
+	FailedPrecondition Code = "failed_precondition"
+	ResourceExhausted  Code = "resource_exhausted"
+	Canceled           Code = "canceled"
+	Unimplemented      Code = "unimplemented"
+	Unavailable        Code = "unavailable"
+	DeadlineExceeded   Code = "deadline_exceeded"
+)
+
+var httpStatus = map[Code]int{
+	Unknown:            http.StatusInternalServerError,
+	Internal:           http.StatusInternalServerError,
+	InvalidArgument:    http.StatusBadRequest,
+	Unauthenticated:    http.StatusUnauthorized,
+	PermissionDenied:   http.StatusForbidden,
+	NotFound:           http.StatusNotFound,
+	AlreadyExists:      http.StatusConflict,
+	Conflict:           http.StatusConflict,
+	FailedPrecondition: http.StatusPreconditionFailed,
+	ResourceExhausted:  http.StatusTooManyRequests,
+	// nginx convention for request canceled by client
+	Canceled:         499,
+	Unimplemented:    http.StatusNotImplemented,
+	Unavailable:      http.StatusServiceUnavailable,
+	DeadlineExceeded: http.StatusGatewayTimeout,
+}
+
+// HTTPStatus returns HTTP status of code, 500 for unknown codes
+func (c Code) HTTPStatus() int {
+	if status, ok := httpStatus[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is error with code and key/value context, empty Code is inherited from Err
+type Error struct {
+	Code    Code
+	Message string
+	Fields  map[string]any
+	Err     error
+}
+
+// New creates error with code, kv are key/value pairs added to Fields
+func New(code Code, msg string, kv ...any) error {
+	return &Error{Code: code, Message: msg, Fields: fields(kv)}
+}
+
+// Wrap annotates err with message and key/value pairs, returns nil if err is nil
+func Wrap(err error, msg string, kv ...any) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Message: msg, Fields: fields(kv), Err: err}
+}
+
+// WrapCode annotates err with message and key/value pairs and overrides its code, returns nil if err is nil
+func WrapCode(err error, code Code, msg string, kv ...any) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Message: msg, Fields: fields(kv), Err: err}
+}
+
+// With adds key/value pairs to err, returns nil if err is nil
+func With(err error, kv ...any) error {
+	return Wrap(err, "", kv...)
+}
+
+// fields converts key/value pairs to map, value without key is stored under !BADKEY
+func fields(kv []any) map[string]any {
+	if len(kv) == 0 {
+		return nil
+	}
+
+	m := make(map[string]any, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			m["!BADKEY"] = kv[i]
+			break
+		}
+
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		m[key] = kv[i+1]
+	}
+	return m
+}
+
+func (e *Error) Error() string {
+	b := strings.Builder{}
+	b.WriteString(e.Message)
+
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s=%v", k, e.Fields[k])
+	}
+
+	if e.Err != nil {
+		if b.Len() > 0 {
+			b.WriteString(": ")
+		}
+		b.WriteString(e.Err.Error())
+	}
+
+	if b.Len() == 0 {
+		return string(e.Code)
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// CodeOf returns code of the outermost coded error in err chain.
+// Context errors are mapped to Canceled and DeadlineExceeded, other errors to Unknown.
+func CodeOf(err error) Code {
+	for err != nil {
+		var e *Error
+		if !stderrors.As(err, &e) {
+			break
+		}
+		if e.Code != "" {
+			return e.Code
+		}
+		err = e.Err
+	}
+
+	switch {
+	case err == nil:
+		return Unknown
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return DeadlineExceeded
+	case stderrors.Is(err, context.Canceled):
+		return Canceled
+	}
+	return Unknown
+}
+
+// FieldsOf returns key/value context of all errors in err chain, outer errors take precedence
+func FieldsOf(err error) map[string]any {
+	m := map[string]any{}
+	for err != nil {
+		var e *Error
+		if !stderrors.As(err, &e) {
+			break
+		}
+		for k, v := range e.Fields {
+			if _, ok := m[k]; !ok {
+				m[k] = v
+			}
+		}
+		err = e.Err
+	}
+	return m
+}
+
+// HTTPStatus returns HTTP status of err, 200 if err is nil
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	return CodeOf(err).HTTPStatus()
+}
+
+// WriteHTTP responds with status of err, message of server errors is hidden
+func WriteHTTP(w http.ResponseWriter, err error) {
+	status := HTTPStatus(err)
+	msg := http.StatusText(status)
+	if status < http.StatusInternalServerError && err != nil {
+		msg = err.Error()
+	}
+	http.Error(w, msg, status)
+}
+
+// Is is errors.Is from the standard library
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// As is errors.As from the standard library
+func As(err error, target any) bool {
+	return stderrors.As(err, target)
+}
+
+// Unwrap is errors.Unwrap from the standard library
+func Unwrap(err error) error {
+	return stderrors.Unwrap(err)
+}
@@ -0,0 +1,96 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var errNotFound = New(NotFound, "user not found")
+
+func TestWrap(t *testing.T) {
+	err := Wrap(errNotFound, "load profile", "id", 7, "tenant", "a")
+	err = fmt.Errorf("handler: %w", With(err, "id", 8))
+
+	if !Is(err, errNotFound) {
+		t.Error("wrapped error not found")
+	}
+	if CodeOf(err) != NotFound || HTTPStatus(err) != http.StatusNotFound {
+		t.Errorf("unexpected code %v", CodeOf(err))
+	}
+	if fields := FieldsOf(err); fields["id"] != 8 || fields["tenant"] != "a" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+	if s := err.Error(); s != "handler: id=8: load profile id=7 tenant=a: user not found" {
+		t.Errorf("unexpected message %q", s)
+	}
+
+	if err := WrapCode(errNotFound, PermissionDenied, "hidden"); CodeOf(err) != PermissionDenied {
+		t.Error("code not overridden")
+	}
+	if Wrap(nil, "x") != nil || With(nil, "k", "v") != nil {
+		t.Error("nil error wrapped")
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	cases := map[error]Code{
+		stderrors.New("plain"):                Unknown,
+		context.DeadlineExceeded:              DeadlineExceeded,
+		Wrap(context.Canceled, "request"):     Canceled,
+		New(ResourceExhausted, "slow down"):   ResourceExhausted,
+		Join(stderrors.New("a"), errNotFound): NotFound,
+	}
+	for err, code := range cases {
+		if CodeOf(err) != code {
+			t.Errorf("%v: expected %v, got %v", err, code, CodeOf(err))
+		}
+	}
+	if HTTPStatus(nil) != http.StatusOK {
+		t.Error("nil error is not 200")
+	}
+}
+
+func TestMulti(t *testing.T) {
+	m := Multi{}
+	if m.Err() != nil {
+		t.Error("empty Multi returned error")
+	}
+
+	errA := stderrors.New("a")
+	m.Add(errA, nil, errNotFound)
+	err := m.Err()
+	if m.Len() != 2 || !Is(err, errA) || !Is(err, errNotFound) {
+		t.Errorf("errors not collected, %v", err)
+	}
+	if err.Error() != "a\nuser not found" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+
+	// methods used by errors.Is and As before Go 1.20
+	multi := err.(*MultiError)
+	var target *Error
+	if !multi.Is(errNotFound) || multi.Is(stderrors.New("a")) || !multi.As(&target) || target != errNotFound {
+		t.Errorf("errors not matched, %v", err)
+	}
+	if Join(nil, nil) != nil {
+		t.Error("Join of nils is not nil")
+	}
+}
+
+func TestWriteHTTP(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteHTTP(w, Wrap(errNotFound, "get"))
+	if w.Code != http.StatusNotFound || w.Body.String() != "get: user not found\n" {
+		t.Errorf("unexpected response %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	WriteHTTP(w, stderrors.New("db password is wrong"))
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "Internal Server Error\n" {
+		t.Errorf("server error not hidden, %q", w.Body.String())
+	}
+}
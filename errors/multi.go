@@ -0,0 +1,92 @@
+package errors
+
+import (
+	stderrors "errors"
+	"strings"
+	"sync"
+)
+
+// MultiError is list of errors, Is and As match any of them
+type MultiError struct {
+	Errors []error
+}
+
+// Join returns MultiError of non-nil errs, nil if there are none
+func Join(errs ...error) error {
+	list := make([]error, 0, len(errs))
+	for _, err := range errs {
+		if err != nil {
+			list = append(list, err)
+		}
+	}
+
+	if len(list) == 0 {
+		return nil
+	}
+	return &MultiError{Errors: list}
+}
+
+func (e *MultiError) Error() string {
+	s := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
+// Is reports whether any of the errors matches target,
+// errors.Is follows Unwrap() []error only since Go 1.20
+func (e *MultiError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if stderrors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors that matches target
+func (e *MultiError) As(target any) bool {
+	for _, err := range e.Errors {
+		if stderrors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Multi collects errors, it's safe for concurrent use
+type Multi struct {
+	lock sync.Mutex
+	errs []error
+}
+
+// Add appends non-nil errs
+func (m *Multi) Add(errs ...error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, err := range errs {
+		if err != nil {
+			m.errs = append(m.errs, err)
+		}
+	}
+}
+
+// Len returns number of collected errors
+func (m *Multi) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.errs)
+}
+
+// Err returns collected errors joined, nil if there are none
+func (m *Multi) Err() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return Join(m.errs...)
+}
@@ -0,0 +1,295 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotLocked   = errors.New("file is not locked")
+	ErrLockLost    = errors.New("file lock was lost")
+	ErrUnsupported = errors.New("file locking is not supported on this platform")
+)
+
+// FileLock is a cross-process lock based on flock.
+// Exclusive holder writes its PID and refresh time into the file.
+type FileLock struct {
+	path string
+
+	mu        sync.Mutex
+	file      *os.File
+	exclusive bool
+
+	breakStale bool
+	expiry     time.Duration
+}
+
+// LockOwner is content of the lock file written by exclusive holder
+type LockOwner struct {
+	PID       int
+	Refreshed time.Time
+	// false if process is not running or lease expired
+	Alive bool
+}
+
+func NewFileLock(path string) *FileLock {
+	return &FileLock{path: path}
+}
+
+// BreakStale enables breaking locks whose exclusive holder is not running,
+// or did not refresh the lock for expiry (disabled if expiry <= 0).
+// Lock file is removed then, so it can't be used when processes may share lock fd.
+// Contenders breaking the lock are serialized by flock on path + ".break" file.
+func (l *FileLock) BreakStale(expiry time.Duration) *FileLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.breakStale = true
+	l.expiry = expiry
+	return l
+}
+
+func (l *FileLock) Path() string {
+	return l.path
+}
+
+// Lock acquires exclusive lock, waits until ctx is done
+func (l *FileLock) Lock(ctx context.Context) error {
+	return l.wait(ctx, true)
+}
+
+// RLock acquires shared lock, waits until ctx is done
+func (l *FileLock) RLock(ctx context.Context) error {
+	return l.wait(ctx, false)
+}
+
+// LockTimeout acquires exclusive lock, returns context.DeadlineExceeded after timeout
+func (l *FileLock) LockTimeout(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return l.Lock(ctx)
+}
+
+// TryLock acquires exclusive lock without waiting, returns false if it is held by other process
+func (l *FileLock) TryLock() (bool, error) {
+	return l.try(true)
+}
+
+// TryRLock acquires shared lock without waiting, returns false if exclusive lock is held by other process
+func (l *FileLock) TryRLock() (bool, error) {
+	return l.try(false)
+}
+
+// wait polls lock, flock itself can't be interrupted
+func (l *FileLock) wait(ctx context.Context, exclusive bool) error {
+	delay := 5 * time.Millisecond
+	for {
+		ok, err := l.try(exclusive)
+		if err != nil || ok {
+			return err
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+
+		if delay < 100*time.Millisecond {
+			delay *= 2
+		}
+	}
+}
+
+func (l *FileLock) try(exclusive bool) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file != nil {
+		return false, fmt.Errorf("%s: already locked by this FileLock", l.path)
+	}
+
+	for {
+		f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return false, err
+		}
+
+		ok, err := flock(f, exclusive)
+		if err != nil || !ok {
+			f.Close()
+			if err == nil && l.breakStale && l.removeStale() {
+				continue
+			}
+			return false, err
+		}
+
+		// file could be removed as stale between open and flock
+		if !l.same(f) {
+			unflock(f)
+			f.Close()
+			continue
+		}
+
+		l.file, l.exclusive = f, exclusive
+		if exclusive {
+			err = l.write()
+		} else if st, _ := f.Stat(); st != nil && st.Size() > 0 {
+			// exclusive holder can't exist now, content is left by crashed one
+			err = f.Truncate(0)
+		}
+		if err != nil {
+			l.release()
+			return false, err
+		}
+		return true, nil
+	}
+}
+
+// same checks that f is the file currently at path
+func (l *FileLock) same(f *os.File) bool {
+	a, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	b, err := os.Stat(l.path)
+	return err == nil && os.SameFile(a, b)
+}
+
+// removeStale removes lock file if its owner is stale, l.mu must be held.
+// Lock file can be replaced only by removing it first, so while the break lock is held
+// the file checked here is the one which is removed.
+func (l *FileLock) removeStale() bool {
+	guard, err := os.OpenFile(l.path+".break", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return false
+	}
+	defer guard.Close()
+
+	if ok, err := flock(guard, true); err != nil || !ok {
+		return false
+	}
+	defer unflock(guard)
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return false
+	}
+	owner, err := parseOwner(l.path, data, l.expiry)
+	if err != nil || owner.PID == 0 || owner.Alive || !l.same(f) {
+		return false
+	}
+	return os.Remove(l.path) == nil
+}
+
+// write writes PID and refresh time, l.mu must be held
+func (l *FileLock) write() error {
+	data := fmt.Sprintf("%d\n%d\n", os.Getpid(), time.Now().UnixNano())
+	if err := l.file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := l.file.WriteAt([]byte(data), 0)
+	return err
+}
+
+// release unlocks and closes file, l.mu must be held
+func (l *FileLock) release() error {
+	if l.exclusive {
+		l.file.Truncate(0)
+	}
+	err := unflock(l.file)
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	l.file = nil
+	return err
+}
+
+// Refresh updates refresh time of exclusive lock,
+// returns ErrLockLost if lock file was removed or replaced
+func (l *FileLock) Refresh() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return ErrNotLocked
+	}
+	if !l.same(l.file) {
+		return ErrLockLost
+	}
+	if !l.exclusive {
+		return nil
+	}
+	return l.write()
+}
+
+func (l *FileLock) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return ErrNotLocked
+	}
+	return l.release()
+}
+
+// Locked returns true if lock is held by this FileLock
+func (l *FileLock) Locked() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file != nil
+}
+
+// Owner returns exclusive holder written in lock file, PID is 0 if there is none
+func (l *FileLock) Owner() (LockOwner, error) {
+	l.mu.Lock()
+	expiry := l.expiry
+	l.mu.Unlock()
+
+	return readOwner(l.path, expiry)
+}
+
+func readOwner(path string, expiry time.Duration) (LockOwner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return LockOwner{}, nil
+		}
+		return LockOwner{}, err
+	}
+	return parseOwner(path, data, expiry)
+}
+
+func parseOwner(path string, data []byte, expiry time.Duration) (LockOwner, error) {
+	lines := strings.Fields(string(data))
+	if len(lines) < 2 {
+		return LockOwner{}, nil
+	}
+
+	pid, err := strconv.Atoi(lines[0])
+	if err != nil {
+		return LockOwner{}, fmt.Errorf("%s: invalid pid: %w", path, err)
+	}
+	nanos, err := strconv.ParseInt(lines[1], 10, 64)
+	if err != nil {
+		return LockOwner{}, fmt.Errorf("%s: invalid refresh time: %w", path, err)
+	}
+
+	owner := LockOwner{PID: pid, Refreshed: time.Unix(0, nanos)}
+	owner.Alive = processAlive(pid) && (expiry <= 0 || time.Since(owner.Refreshed) <= expiry)
+	return owner, nil
+}
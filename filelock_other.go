@@ -0,0 +1,18 @@
+//go:build !unix
+
+package utils
+
+import "os"
+
+func flock(f *os.File, exclusive bool) (bool, error) {
+	return false, ErrUnsupported
+}
+
+func unflock(f *os.File) error {
+	return ErrUnsupported
+}
+
+// processAlive assumes process is running, stale locks are detected only by expiry
+func processAlive(pid int) bool {
+	return pid > 0
+}
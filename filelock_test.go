@@ -0,0 +1,178 @@
+//go:build unix
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+	a, b := NewFileLock(path), NewFileLock(path)
+
+	if err := a.Lock(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.TryRLock(); ok || err != nil {
+		t.Errorf("exclusive lock not respected, %v", err)
+	}
+	if err := b.LockTimeout(20 * time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	owner, err := b.Owner()
+	if err != nil || owner.PID != os.Getpid() || !owner.Alive {
+		t.Errorf("unexpected owner %+v %v", owner, err)
+	}
+
+	if err := a.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Unlock(); err != ErrNotLocked {
+		t.Errorf("expected ErrNotLocked, got %v", err)
+	}
+	if owner, _ := b.Owner(); owner.PID != 0 {
+		t.Errorf("owner not cleared %+v", owner)
+	}
+}
+
+func TestFileLockShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+	a, b, c := NewFileLock(path), NewFileLock(path), NewFileLock(path)
+
+	if ok, _ := a.TryRLock(); !ok {
+		t.Fatal("shared lock not acquired")
+	}
+	if ok, _ := b.TryRLock(); !ok {
+		t.Fatal("shared lock not shared")
+	}
+	if ok, _ := c.TryLock(); ok {
+		t.Fatal("exclusive lock acquired while shared is held")
+	}
+
+	a.Unlock()
+	b.Unlock()
+	if ok, _ := c.TryLock(); !ok {
+		t.Fatal("exclusive lock not acquired")
+	}
+	c.Unlock()
+}
+
+func TestFileLockStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+	a := NewFileLock(path)
+	b := NewFileLock(path).BreakStale(20 * time.Millisecond)
+
+	if ok, _ := a.TryLock(); !ok {
+		t.Fatal("lock not acquired")
+	}
+
+	// holder which does not refresh its lock is stale after expiry
+	time.Sleep(30 * time.Millisecond)
+	if err := b.LockTimeout(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Refresh(); err != ErrLockLost {
+		t.Errorf("expected ErrLockLost, got %v", err)
+	}
+
+	// dead process
+	b.Unlock()
+	os.WriteFile(path, []byte(fmt.Sprintf("%d\n%d\n", 1<<22+1, time.Now().UnixNano())), 0o644)
+	if owner, _ := b.Owner(); owner.Alive {
+		t.Errorf("dead owner reported alive %+v", owner)
+	}
+}
+
+func TestFileLockStaleRace(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		path := filepath.Join(t.TempDir(), "lock")
+		a := NewFileLock(path)
+		if ok, _ := a.TryLock(); !ok {
+			t.Fatal("lock not acquired")
+		}
+		// lease of a expired long ago
+		os.WriteFile(path, []byte(fmt.Sprintf("%d\n%d\n", os.Getpid(), time.Now().Add(-time.Hour).UnixNano())), 0o644)
+
+		var (
+			wg       sync.WaitGroup
+			start    = make(chan struct{})
+			acquired int32
+		)
+		for j := 0; j < 4; j++ {
+			l := NewFileLock(path).BreakStale(time.Minute)
+			defer l.Unlock()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				if ok, _ := l.TryLock(); ok {
+					atomic.AddInt32(&acquired, 1)
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		if acquired > 1 {
+			t.Fatalf("stale lock acquired by %d contenders", acquired)
+		}
+		a.Unlock()
+	}
+}
+
+func TestElection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader")
+
+	var elected, revoked int32
+	newElection := func() *Election {
+		return NewElection(path, 30*time.Millisecond).
+			OnElected(func(ctx context.Context) { atomic.AddInt32(&elected, 1) }).
+			OnRevoked(func() { atomic.AddInt32(&revoked, 1) })
+	}
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+
+	a, b := newElection(), newElection()
+	doneA := make(chan struct{})
+	go func() {
+		a.Run(ctxA)
+		close(doneA)
+	}()
+	waitUntil(t, a.IsLeader)
+
+	go b.Run(ctxB)
+	time.Sleep(50 * time.Millisecond)
+	if b.IsLeader() {
+		t.Fatal("two leaders")
+	}
+
+	// leader resigns, the other one takes over
+	cancelA()
+	<-doneA
+	waitUntil(t, b.IsLeader)
+	waitUntil(t, func() bool { return atomic.LoadInt32(&elected) == 2 })
+
+	if atomic.LoadInt32(&revoked) != 1 {
+		t.Errorf("%d revoked", atomic.LoadInt32(&revoked))
+	}
+}
+
+func waitUntil(t *testing.T, fn func() bool) {
+	t.Helper()
+	for i := 0; !fn(); i++ {
+		if i > 200 {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
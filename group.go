@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/timoni-io/go-utils/errors"
+)
+
+// Group runs tasks in goroutines and waits for them.
+// By default the first error cancels group context and is returned from Wait,
+// with CollectAll every task runs to the end and all errors are returned.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	sem     chan struct{}
+	collect bool
+
+	lock    sync.Mutex
+	running int
+	// closed when no task is running
+	idle chan struct{}
+	errs []error
+}
+
+// NewGroup creates group with context derived from ctx
+func NewGroup(ctx context.Context) *Group {
+	g := &Group{idle: make(chan struct{})}
+	g.ctx, g.cancel = context.WithCancel(ctx)
+	close(g.idle)
+	return g
+}
+
+// Limit sets max number of concurrently running tasks, Go blocks when limit is reached
+func (g *Group) Limit(n int) *Group {
+	if n > 0 {
+		g.sem = make(chan struct{}, n)
+	} else {
+		g.sem = nil
+	}
+	return g
+}
+
+// CollectAll disables cancellation on first error, Wait returns all errors joined
+func (g *Group) CollectAll() *Group {
+	g.collect = true
+	return g
+}
+
+// Context returns context passed to tasks
+func (g *Group) Context() context.Context {
+	return g.ctx
+}
+
+// Go runs fn in new goroutine, panic in fn is returned as error
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	sem := g.sem
+	if sem != nil {
+		sem <- struct{}{}
+	}
+
+	g.lock.Lock()
+	if g.running == 0 {
+		g.idle = make(chan struct{})
+	}
+	g.running++
+	g.lock.Unlock()
+
+	go func() {
+		err := g.call(fn)
+
+		g.lock.Lock()
+		if err != nil {
+			g.errs = append(g.errs, err)
+			if !g.collect {
+				g.cancel()
+			}
+		}
+		g.running--
+		if g.running == 0 {
+			close(g.idle)
+		}
+		g.lock.Unlock()
+
+		if sem != nil {
+			<-sem
+		}
+	}()
+}
+
+func (g *Group) call(fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = CapturePanic(r)
+		}
+	}()
+	return fn(g.ctx)
+}
+
+// Wait waits for all tasks
+func (g *Group) Wait() error {
+	return g.WaitContext(context.Background())
+}
+
+// WaitTimeout waits for all tasks at most timeout, returns ErrTimeout if waiting timed out
+func (g *Group) WaitTimeout(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if !g.wait(ctx) {
+		return ErrTimeout
+	}
+	return g.result()
+}
+
+// WaitContext waits for all tasks or until ctx is done.
+// Returns the first task error, all errors joined with CollectAll, or ctx error.
+// Group context is canceled after all tasks finished.
+func (g *Group) WaitContext(ctx context.Context) error {
+	if !g.wait(ctx) {
+		return ctx.Err()
+	}
+	return g.result()
+}
+
+// wait returns false if ctx was done before tasks finished
+func (g *Group) wait(ctx context.Context) bool {
+	g.lock.Lock()
+	idle := g.idle
+	g.lock.Unlock()
+
+	select {
+	case <-idle:
+		g.cancel()
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (g *Group) result() error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	switch {
+	case len(g.errs) == 0:
+		return nil
+	case !g.collect:
+		return g.errs[0]
+	}
+	return errors.Join(g.errs...)
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroup(t *testing.T) {
+	errTest := errors.New("test")
+	g := NewGroup(context.Background())
+
+	g.Go(func(ctx context.Context) error { return errTest })
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if err := g.Wait(); err != errTest {
+		t.Errorf("expected first error, got %v", err)
+	}
+}
+
+func TestGroupCollectAll(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	g := NewGroup(context.Background()).CollectAll()
+
+	g.Go(func(ctx context.Context) error { return errA })
+	g.Go(func(ctx context.Context) error { return errB })
+	g.Go(func(ctx context.Context) error { panic("boom") })
+	g.Go(func(ctx context.Context) error {
+		time.Sleep(10 * time.Millisecond)
+		return ctx.Err()
+	})
+
+	err := g.Wait()
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("errors not collected, %v", err)
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 3 {
+		t.Errorf("expected 3 errors, got %d", n)
+	}
+}
+
+func TestGroupLimit(t *testing.T) {
+	g := NewGroup(context.Background()).Limit(2)
+
+	var running, peak atomic.Int64
+	for i := 0; i < 6; i++ {
+		g.Go(func(ctx context.Context) error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if peak.Load() > 2 {
+		t.Errorf("limit not respected, %d running", peak.Load())
+	}
+}
+
+func TestGroupWaitTimeout(t *testing.T) {
+	g := NewGroup(context.Background())
+	release := make(chan struct{})
+	g.Go(func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	if err := g.WaitTimeout(5 * time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := g.WaitContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	close(release)
+	if err := g.Wait(); err != nil || g.Context().Err() == nil {
+		t.Errorf("group not finished, %v", err)
+	}
+}
+
+func TestWaitWithTimeout(t *testing.T) {
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	if err := WaitWithTimeout(&wg, 5*time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout, got %v", err)
+	}
+
+	wg.Done()
+	if err := WaitWithTimeout(&wg, 5*time.Millisecond); err != nil {
+		t.Error(err)
+	}
+}
@@ -51,34 +51,133 @@ func FilterMap[K comparable, V any](val map[K]V, fn FilterFunc[V]) map[K]V {
 	return newValues
 }
 
-func Until[V any](val []V, fn func(x V) bool) (until []V, rest []V) {
+// Until splits slice before the first element for which fn returns true.
+// If no element matches, until is the whole slice and rest is nil.
+//
+//	Until([1 2 3 4], x == 3) -> [1 2], [3 4]
+//	Until([1 2], x == 3)     -> [1 2], []
+func Until[V any](val []V, fn FilterFunc[V]) (until []V, rest []V) {
 	for i, v := range val {
 		if fn(v) {
-			until = val[:i]
-			rest = val[i:]
-			break
+			return val[:i], val[i:]
 		}
 	}
-	return until, rest
+	return val, nil
+}
+
+// SplitMode decides what happens with separators when splitting
+type SplitMode uint8
+
+const (
+	// separators are dropped, consecutive separators produce empty parts
+	//	[a | b | | c] -> [a] [b] [] [c]
+	DropSeparator SplitMode = iota
+	// separators are separate parts, there are no empty parts
+	//	[a | b | | c] -> [a] [|] [b] [|] [|] [c]
+	KeepSeparator
+	// separators start new part, there are no empty parts
+	//	[a | b | | c] -> [a] [| b] [|] [| c]
+	AttachBefore
+	// separators end part, there are no empty parts
+	//	[a | b | | c] -> [a |] [b |] [|] [c]
+	AttachAfter
+)
+
+// Split splits slice on elements for which fn returns true, separators are dropped.
+// Parts share memory with val. Returns nil for empty slice.
+func Split[V any](val []V, fn FilterFunc[V]) [][]V {
+	return SplitWith(val, fn, DropSeparator)
+}
+
+// SplitAfter splits slice after every element for which fn returns true
+func SplitAfter[V any](val []V, fn FilterFunc[V]) [][]V {
+	return SplitWith(val, fn, AttachAfter)
+}
+
+// SplitWith splits slice on elements for which fn returns true, see SplitMode.
+// Parts share memory with val. Returns nil for empty slice.
+func SplitWith[V any](val []V, fn FilterFunc[V], mode SplitMode) (parts [][]V) {
+	return splitN(val, fn, mode, -1)
+}
+
+// SplitN is Split returning at most n parts, the last part is the unsplit remainder.
+// For n == 0 returns nil, for n < 0 returns all parts.
+func SplitN[V any](val []V, fn FilterFunc[V], n int) [][]V {
+	return splitN(val, fn, DropSeparator, n)
 }
 
-func Split[V any](val []V, fn func(x V) bool) (parts [][]V) {
-	if val == nil {
+func splitN[V any](val []V, fn FilterFunc[V], mode SplitMode, n int) (parts [][]V) {
+	if len(val) == 0 || n == 0 {
 		return nil
 	}
 
-	lastI := 0
+	// appends non empty part, or any part in DropSeparator mode
+	add := func(part []V) {
+		if len(part) > 0 || mode == DropSeparator {
+			parts = append(parts, part)
+		}
+	}
+
+	start := 0
 	for i, v := range val {
+		if n > 0 && len(parts) == n-1 {
+			break
+		}
+		if !fn(v) {
+			continue
+		}
+
+		switch mode {
+		case DropSeparator:
+			add(val[start:i])
+			start = i + 1
+		case KeepSeparator:
+			add(val[start:i])
+			add(val[i : i+1])
+			start = i + 1
+		case AttachBefore:
+			add(val[start:i])
+			start = i
+		case AttachAfter:
+			add(val[start : i+1])
+			start = i + 1
+		}
+	}
+
+	if start < len(val) || mode == DropSeparator {
+		add(val[start:])
+	}
+
+	return parts
+}
+
+// Partition returns elements for which fn returns true and the rest, keeping order
+func Partition[V any](val []V, fn FilterFunc[V]) (matching []V, rest []V) {
+	for _, v := range val {
 		if fn(v) {
-			parts = append(parts, val[lastI:i])
-			lastI = i
+			matching = append(matching, v)
+		} else {
+			rest = append(rest, v)
 		}
 	}
+	return matching, rest
+}
 
-	if lastI < len(val) {
-		parts = append(parts, val[lastI:])
+// ChunkSlice splits slice into parts of size n, last part may be shorter.
+// Parts share memory with val. Returns nil if n <= 0.
+func ChunkSlice[V any](val []V, n int) [][]V {
+	if n <= 0 || len(val) == 0 {
+		return nil
 	}
 
+	parts := make([][]V, 0, (len(val)+n-1)/n)
+	for i := 0; i < len(val); i += n {
+		end := i + n
+		if end > len(val) {
+			end = len(val)
+		}
+		parts = append(parts, val[i:end:end])
+	}
 	return parts
 }
 
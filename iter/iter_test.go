@@ -0,0 +1,125 @@
+package iter
+
+import (
+	"fmt"
+	"testing"
+)
+
+func isSep(x string) bool { return x == "|" }
+
+func TestUntil(t *testing.T) {
+	tests := []struct {
+		in          []string
+		until, rest string
+	}{
+		{nil, "[]", "[]"},
+		{[]string{"a", "b"}, "[a b]", "[]"},
+		{[]string{"a", "|", "b"}, "[a]", "[| b]"},
+		{[]string{"|", "a"}, "[]", "[| a]"},
+	}
+
+	for _, tt := range tests {
+		until, rest := Until(tt.in, isSep)
+		if fmt.Sprint(until) != tt.until || fmt.Sprint(rest) != tt.rest {
+			t.Errorf("Until(%v) = %v, %v; want %s, %s", tt.in, until, rest, tt.until, tt.rest)
+		}
+	}
+	if until, _ := Until([]string{"a"}, isSep); until == nil {
+		t.Error("Until returned nil for input without separator")
+	}
+}
+
+func TestSplitWith(t *testing.T) {
+	in := []string{"a", "|", "b", "|", "|", "c"}
+
+	tests := []struct {
+		in   []string
+		mode SplitMode
+		want string
+	}{
+		{nil, DropSeparator, "[]"},
+		{in, DropSeparator, "[[a] [b] [] [c]]"},
+		{in, KeepSeparator, "[[a] [|] [b] [|] [|] [c]]"},
+		{in, AttachBefore, "[[a] [| b] [|] [| c]]"},
+		{in, AttachAfter, "[[a |] [b |] [|] [c]]"},
+		{[]string{"|", "a", "|"}, DropSeparator, "[[] [a] []]"},
+		{[]string{"|", "a", "|"}, KeepSeparator, "[[|] [a] [|]]"},
+		{[]string{"|", "a", "|"}, AttachBefore, "[[| a] [|]]"},
+		{[]string{"|", "a", "|"}, AttachAfter, "[[|] [a |]]"},
+		{[]string{"a", "b"}, DropSeparator, "[[a b]]"},
+		{[]string{"a", "b"}, AttachAfter, "[[a b]]"},
+	}
+
+	for _, tt := range tests {
+		if got := fmt.Sprint(SplitWith(tt.in, isSep, tt.mode)); got != tt.want {
+			t.Errorf("SplitWith(%v, %d) = %s; want %s", tt.in, tt.mode, got, tt.want)
+		}
+	}
+
+	if got := fmt.Sprint(Split(in, isSep)); got != "[[a] [b] [] [c]]" {
+		t.Errorf("Split = %s", got)
+	}
+	if got := fmt.Sprint(SplitAfter(in, isSep)); got != "[[a |] [b |] [|] [c]]" {
+		t.Errorf("SplitAfter = %s", got)
+	}
+}
+
+func TestSplitN(t *testing.T) {
+	in := []string{"a", "|", "b", "|", "c"}
+
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "[]"},
+		{1, "[[a | b | c]]"},
+		{2, "[[a] [b | c]]"},
+		{3, "[[a] [b] [c]]"},
+		{10, "[[a] [b] [c]]"},
+		{-1, "[[a] [b] [c]]"},
+	}
+
+	for _, tt := range tests {
+		if got := fmt.Sprint(SplitN(in, isSep, tt.n)); got != tt.want {
+			t.Errorf("SplitN(%d) = %s; want %s", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestPartition(t *testing.T) {
+	tests := []struct {
+		in             []int
+		matching, rest string
+	}{
+		{nil, "[]", "[]"},
+		{[]int{1, 2, 3, 4}, "[2 4]", "[1 3]"},
+		{[]int{1, 3}, "[]", "[1 3]"},
+	}
+
+	for _, tt := range tests {
+		matching, rest := Partition(tt.in, func(x int) bool { return x%2 == 0 })
+		if fmt.Sprint(matching) != tt.matching || fmt.Sprint(rest) != tt.rest {
+			t.Errorf("Partition(%v) = %v, %v", tt.in, matching, rest)
+		}
+	}
+}
+
+func TestChunkSlice(t *testing.T) {
+	tests := []struct {
+		in   []int
+		n    int
+		want string
+	}{
+		{nil, 2, "[]"},
+		{[]int{1, 2, 3}, 0, "[]"},
+		{[]int{1, 2, 3}, 2, "[[1 2] [3]]"},
+		{[]int{1, 2, 3, 4}, 2, "[[1 2] [3 4]]"},
+		{[]int{1, 2}, 5, "[[1 2]]"},
+	}
+
+	for _, tt := range tests {
+		if got := fmt.Sprint(ChunkSlice(tt.in, tt.n)); got != tt.want {
+			t.Errorf("ChunkSlice(%v, %d) = %s; want %s", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package iter
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/timoni-io/go-utils"
+	"github.com/timoni-io/go-utils/errors"
+)
+
+type ErrorMode uint8
+
+const (
+	// stop scheduling new elements and return the first error
+	FailFast ErrorMode = iota
+	// process all elements and return errors.MultiError with every failure, ordered by element index
+	CollectAll
+)
+
+type indexedError struct {
+	idx int
+	err error
+}
+
+// call runs fn converting panic to error
+func call(ctx context.Context, i int, fn func(ctx context.Context, i int) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = utils.CapturePanic(r)
+		}
+	}()
+	return fn(ctx, i)
+}
+
+// parallel runs fn for indexes [0, n) with at most limit goroutines
+func parallel(ctx context.Context, n, limit int, mode ErrorMode, fn func(ctx context.Context, i int) error) error {
+	if limit <= 0 {
+		limit = runtime.NumCPU()
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []indexedError
+		sem  = make(chan struct{}, limit)
+	)
+
+schedule:
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-runCtx.Done():
+			break schedule
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if runCtx.Err() != nil {
+				return
+			}
+
+			if err := call(runCtx, i, fn); err != nil {
+				mu.Lock()
+				errs = append(errs, indexedError{idx: i, err: fmt.Errorf("element %d: %w", i, err)})
+				mu.Unlock()
+
+				if mode == FailFast {
+					cancel()
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		if mode == FailFast {
+			return errs[0].err
+		}
+
+		sort.Slice(errs, func(i, j int) bool { return errs[i].idx < errs[j].idx })
+		out := make([]error, len(errs))
+		for i, e := range errs {
+			out[i] = e.err
+		}
+		return errors.Join(out...)
+	}
+
+	return ctx.Err()
+}
+
+// ParallelMap calls fn for every element using at most limit goroutines (NumCPU if limit <= 0).
+// Output keeps input order. Returns error if fn panicked or ctx is done.
+func ParallelMap[V any, O any](ctx context.Context, val []V, limit int, fn MapFunc[V, O]) ([]O, error) {
+	return ParallelMapErr(ctx, val, limit, FailFast, func(_ context.Context, x V) (O, error) {
+		return fn(x), nil
+	})
+}
+
+// ParallelMapErr calls fn for every element using at most limit goroutines (NumCPU if limit <= 0).
+// Output keeps input order, elements which failed have zero value.
+func ParallelMapErr[V any, O any](ctx context.Context, val []V, limit int, mode ErrorMode, fn func(ctx context.Context, x V) (O, error)) ([]O, error) {
+	out := make([]O, len(val))
+	err := parallel(ctx, len(val), limit, mode, func(ctx context.Context, i int) (err error) {
+		out[i], err = fn(ctx, val[i])
+		return err
+	})
+	return out, err
+}
+
+// ParallelFilter calls fn for every element using at most limit goroutines (NumCPU if limit <= 0).
+// Output keeps input order.
+func ParallelFilter[V any](ctx context.Context, val []V, limit int, fn FilterFunc[V]) ([]V, error) {
+	keep := make([]bool, len(val))
+	err := parallel(ctx, len(val), limit, FailFast, func(_ context.Context, i int) error {
+		keep[i] = fn(val[i])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]V, 0, len(val))
+	for i, v := range val {
+		if keep[i] {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// ParallelForEach calls fn for every element using at most limit goroutines (NumCPU if limit <= 0)
+func ParallelForEach[V any](ctx context.Context, val []V, limit int, mode ErrorMode, fn func(ctx context.Context, x V) error) error {
+	return parallel(ctx, len(val), limit, mode, func(ctx context.Context, i int) error {
+		return fn(ctx, val[i])
+	})
+}
+
+// ParallelReduce maps elements in parallel and folds results in input order
+func ParallelReduce[V any, O any, A any](ctx context.Context, val []V, limit int, fn MapFunc[V, O], init A, reduce func(acc A, x O) A) (A, error) {
+	mapped, err := ParallelMap(ctx, val, limit, fn)
+	if err != nil {
+		return init, err
+	}
+
+	acc := init
+	for _, x := range mapped {
+		acc = reduce(acc, x)
+	}
+	return acc, nil
+}
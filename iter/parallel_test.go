@@ -0,0 +1,94 @@
+package iter
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/timoni-io/go-utils/errors"
+	"github.com/timoni-io/go-utils/slice"
+)
+
+func TestParallelMap(t *testing.T) {
+	var running, peak int32
+
+	out, err := ParallelMap(context.Background(), []int{1, 2, 3, 4, 5, 6}, 2, func(x int) int {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return x * 10
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slice.Equal(out, []int{10, 20, 30, 40, 50, 60}) {
+		t.Errorf("invalid output %v", out)
+	}
+	if peak > 2 {
+		t.Errorf("concurrency limit exceeded %d", peak)
+	}
+}
+
+func TestParallelMapErr(t *testing.T) {
+	errOdd := errors.New(errors.InvalidArgument, "odd")
+	fn := func(ctx context.Context, x int) (int, error) {
+		if x%2 == 1 {
+			return 0, errOdd
+		}
+		return x, nil
+	}
+
+	_, err := ParallelMapErr(context.Background(), []int{1, 2, 3, 4}, 1, FailFast, fn)
+	if err == nil || !errors.Is(err, errOdd) {
+		t.Errorf("invalid err %v", err)
+	}
+
+	out, err := ParallelMapErr(context.Background(), []int{1, 2, 3, 4}, 2, CollectAll, fn)
+	var errs *errors.MultiError
+	if !errors.As(err, &errs) || len(errs.Errors) != 2 {
+		t.Fatalf("invalid err %v", err)
+	}
+	if errs.Errors[0].Error() != "element 0: odd" {
+		t.Errorf("invalid err %v", errs.Errors[0])
+	}
+	if !slice.Equal(out, []int{0, 2, 0, 4}) {
+		t.Errorf("invalid output %v", out)
+	}
+}
+
+func TestParallelPanic(t *testing.T) {
+	err := ParallelForEach(context.Background(), []int{1}, 0, FailFast, func(ctx context.Context, x int) error {
+		panic(fmt.Sprint("boom ", x))
+	})
+
+	if err == nil || err.Error() != "element 0: panic: boom 1" {
+		t.Errorf("invalid err %v", err)
+	}
+}
+
+func TestParallelCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	_, err := ParallelFilter(ctx, []int{1, 2, 3}, 1, func(x int) bool {
+		calls++
+		return true
+	})
+
+	if err != context.Canceled {
+		t.Errorf("invalid err %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("fn called %d times", calls)
+	}
+}
@@ -0,0 +1,394 @@
+package iter
+
+import (
+	"context"
+	"sync"
+
+	"github.com/timoni-io/go-utils/maps"
+	"github.com/timoni-io/go-utils/set"
+	"github.com/timoni-io/go-utils/types"
+)
+
+// Seq is a lazy sequence, it calls yield for every element until yield returns false.
+// Elements are computed only when consumed.
+type Seq[T any] func(yield func(v T) bool)
+
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// --- sources ---
+
+func FromSlice[T any](val []T) Seq[T] {
+	return func(yield func(v T) bool) {
+		for _, v := range val {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// FromChan reads channel until it is closed or consumer stops
+func FromChan[T any](ch <-chan T) Seq[T] {
+	return func(yield func(v T) bool) {
+		for v := range ch {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// FromChanContext reads channel until it is closed, ctx is done or consumer stops
+func FromChanContext[T any](ctx context.Context, ch <-chan T) Seq[T] {
+	return func(yield func(v T) bool) {
+		for {
+			select {
+			case v, ok := <-ch:
+				if !ok || !yield(v) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func FromIterator[K comparable, V any](it types.Iterator[K, V]) Seq[types.Item[K, V]] {
+	return FromChan((<-chan types.Item[K, V])(it))
+}
+
+// FromSet ranges over set, set read lock is held while sequence is consumed
+func FromSet[T comparable](s *set.Set[T]) Seq[T] {
+	return func(yield func(v T) bool) {
+		s.Range(yield)
+	}
+}
+
+// FromMap ranges over map, map read lock is held while sequence is consumed
+func FromMap[K comparable, V any](m *maps.Map[K, V]) Seq[types.Item[K, V]] {
+	return func(yield func(v types.Item[K, V]) bool) {
+		m.Range(func(k K, v V) bool {
+			return yield(types.Item[K, V]{Key: k, Value: v})
+		})
+	}
+}
+
+// Range returns numbers from start to end, end excluded
+func Range(start, end int) Seq[int] {
+	return func(yield func(v int) bool) {
+		for i := start; i < end; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+// --- transformations ---
+
+func (s Seq[T]) Filter(fn FilterFunc[T]) Seq[T] {
+	return func(yield func(v T) bool) {
+		s(func(v T) bool {
+			if !fn(v) {
+				return true
+			}
+			return yield(v)
+		})
+	}
+}
+
+// Take returns first n elements
+func (s Seq[T]) Take(n int) Seq[T] {
+	return func(yield func(v T) bool) {
+		if n <= 0 {
+			return
+		}
+
+		i := 0
+		s(func(v T) bool {
+			i++
+			return yield(v) && i < n
+		})
+	}
+}
+
+// Skip skips first n elements
+func (s Seq[T]) Skip(n int) Seq[T] {
+	return func(yield func(v T) bool) {
+		i := 0
+		s(func(v T) bool {
+			if i < n {
+				i++
+				return true
+			}
+			return yield(v)
+		})
+	}
+}
+
+// Chunk groups elements into slices of size n, last chunk may be shorter
+func Chunk[T any](s Seq[T], n int) Seq[[]T] {
+	return func(yield func(v []T) bool) {
+		if n <= 0 {
+			return
+		}
+
+		chunk := make([]T, 0, n)
+		stopped := false
+		s(func(v T) bool {
+			chunk = append(chunk, v)
+			if len(chunk) < n {
+				return true
+			}
+
+			if !yield(chunk) {
+				stopped = true
+				return false
+			}
+			chunk = make([]T, 0, n)
+			return true
+		})
+
+		if !stopped && len(chunk) > 0 {
+			yield(chunk)
+		}
+	}
+}
+
+// Window returns sliding windows of n consecutive elements
+func Window[T any](s Seq[T], n int) Seq[[]T] {
+	return func(yield func(v []T) bool) {
+		if n <= 0 {
+			return
+		}
+
+		window := make([]T, 0, n)
+		s(func(v T) bool {
+			if len(window) == n {
+				copy(window, window[1:])
+				window = window[:n-1]
+			}
+			window = append(window, v)
+			if len(window) < n {
+				return true
+			}
+
+			out := make([]T, n)
+			copy(out, window)
+			return yield(out)
+		})
+	}
+}
+
+func Map[T any, O any](s Seq[T], fn MapFunc[T, O]) Seq[O] {
+	return func(yield func(v O) bool) {
+		s(func(v T) bool {
+			return yield(fn(v))
+		})
+	}
+}
+
+func FlatMap[T any, O any](s Seq[T], fn func(x T) Seq[O]) Seq[O] {
+	return func(yield func(v O) bool) {
+		stopped := false
+		s(func(v T) bool {
+			fn(v)(func(o O) bool {
+				if !yield(o) {
+					stopped = true
+				}
+				return !stopped
+			})
+			return !stopped
+		})
+	}
+}
+
+// Distinct skips elements which were already seen
+func Distinct[T comparable](s Seq[T]) Seq[T] {
+	return func(yield func(v T) bool) {
+		seen := set.New[T]()
+		s(func(v T) bool {
+			if seen.Contains(v) {
+				return true
+			}
+			seen.Add(v)
+			return yield(v)
+		})
+	}
+}
+
+// Zip pairs elements of both sequences, stops when any of them ends
+func Zip[A any, B any](a Seq[A], b Seq[B]) Seq[Pair[A, B]] {
+	return func(yield func(v Pair[A, B]) bool) {
+		next, stop := Pull(b)
+		defer stop()
+
+		a(func(x A) bool {
+			y, ok := next()
+			if !ok {
+				return false
+			}
+			return yield(Pair[A, B]{First: x, Second: y})
+		})
+	}
+}
+
+// Pull converts sequence to next function, stop must be called when done.
+// Sequence runs in separate goroutine which ends after stop.
+func Pull[T any](s Seq[T]) (next func() (T, bool), stop func()) {
+	req := make(chan struct{})
+	res := make(chan T)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+
+		select {
+		case <-req:
+		case <-done:
+			return
+		}
+
+		s(func(v T) bool {
+			select {
+			case res <- v:
+			case <-done:
+				return false
+			}
+
+			select {
+			case <-req:
+				return true
+			case <-done:
+				return false
+			}
+		})
+	}()
+
+	next = func() (v T, ok bool) {
+		select {
+		case req <- struct{}{}:
+		case <-finished:
+			return
+		}
+
+		select {
+		case v = <-res:
+			return v, true
+		case <-finished:
+			return
+		}
+	}
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() { close(done) })
+		<-finished
+	}
+
+	return next, stop
+}
+
+// --- consumers ---
+
+func (s Seq[T]) ForEach(fn func(v T)) {
+	s(func(v T) bool {
+		fn(v)
+		return true
+	})
+}
+
+func (s Seq[T]) Collect() []T {
+	out := []T{}
+	s(func(v T) bool {
+		out = append(out, v)
+		return true
+	})
+	return out
+}
+
+func (s Seq[T]) Count() int {
+	count := 0
+	s(func(v T) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// First returns first element, false if sequence is empty
+func (s Seq[T]) First() (first T, ok bool) {
+	s(func(v T) bool {
+		first, ok = v, true
+		return false
+	})
+	return
+}
+
+func (s Seq[T]) Any(fn FilterFunc[T]) bool {
+	_, ok := s.Filter(fn).First()
+	return ok
+}
+
+func (s Seq[T]) All(fn FilterFunc[T]) bool {
+	return !s.Any(func(x T) bool { return !fn(x) })
+}
+
+// Chan sends elements to returned channel until sequence ends or ctx is done
+func (s Seq[T]) Chan(ctx context.Context) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		s(func(v T) bool {
+			select {
+			case out <- v:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return out
+}
+
+func Reduce[T any, A any](s Seq[T], init A, fn func(acc A, v T) A) A {
+	acc := init
+	s(func(v T) bool {
+		acc = fn(acc, v)
+		return true
+	})
+	return acc
+}
+
+func GroupBy[T any, K comparable](s Seq[T], key func(v T) K) map[K][]T {
+	out := map[K][]T{}
+	s(func(v T) bool {
+		k := key(v)
+		out[k] = append(out[k], v)
+		return true
+	})
+	return out
+}
+
+func CollectMap[K comparable, V any](s Seq[types.Item[K, V]]) map[K]V {
+	out := map[K]V{}
+	s(func(v types.Item[K, V]) bool {
+		out[v.Key] = v.Value
+		return true
+	})
+	return out
+}
+
+func CollectSet[T comparable](s Seq[T]) *set.Set[T] {
+	out := set.New[T]()
+	s(func(v T) bool {
+		out.Add(v)
+		return true
+	})
+	return out
+}
@@ -0,0 +1,89 @@
+package iter
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/timoni-io/go-utils/maps"
+	"github.com/timoni-io/go-utils/set"
+	"github.com/timoni-io/go-utils/slice"
+)
+
+func TestSeqLazy(t *testing.T) {
+	calls := 0
+	out := Map(Range(0, 1000), func(x int) int {
+		calls++
+		return x * 2
+	}).Filter(func(x int) bool { return x%4 == 0 }).Skip(1).Take(3).Collect()
+
+	if !slice.Equal(out, []int{4, 8, 12}) {
+		t.Errorf("invalid result %v", out)
+	}
+	if calls != 7 {
+		t.Errorf("evaluated %d elements", calls)
+	}
+}
+
+func TestSeqChunkWindow(t *testing.T) {
+	chunks := Chunk(Range(0, 5), 2).Collect()
+	if fmt.Sprint(chunks) != "[[0 1] [2 3] [4]]" {
+		t.Errorf("invalid chunks %v", chunks)
+	}
+
+	windows := Window(Range(0, 4), 3).Collect()
+	if fmt.Sprint(windows) != "[[0 1 2] [1 2 3]]" {
+		t.Errorf("invalid windows %v", windows)
+	}
+}
+
+func TestSeqFlatMapDistinct(t *testing.T) {
+	out := Distinct(FlatMap(FromSlice([]int{1, 2, 3}), func(x int) Seq[int] {
+		return Range(0, x)
+	})).Collect()
+
+	if !slice.Equal(out, []int{0, 1, 2}) {
+		t.Errorf("invalid result %v", out)
+	}
+}
+
+func TestSeqZip(t *testing.T) {
+	goroutines := runtime.NumGoroutine()
+
+	out := Zip(FromSlice([]string{"a", "b", "c"}), Range(0, 100)).Collect()
+	if len(out) != 3 || out[2].First != "c" || out[2].Second != 2 {
+		t.Errorf("invalid result %v", out)
+	}
+
+	for i := 0; runtime.NumGoroutine() > goroutines; i++ {
+		if i == 100 {
+			t.Fatal("pull goroutine leaked")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSeqCollect(t *testing.T) {
+	groups := GroupBy(Range(0, 6), func(x int) bool { return x%2 == 0 })
+	if len(groups[true]) != 3 || len(groups[false]) != 3 {
+		t.Errorf("invalid groups %v", groups)
+	}
+
+	sum := Reduce(FromSet(set.New(1, 2, 3)), 0, func(acc, x int) int { return acc + x })
+	if sum != 6 {
+		t.Errorf("invalid sum %d", sum)
+	}
+
+	m := CollectMap(FromMap(maps.New(map[string]int{"a": 1})))
+	if m["a"] != 1 {
+		t.Fail()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if CollectSet(FromChan(Range(0, 3).Chan(ctx))).Length() != 3 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+// KeyedLock is a set of locks identified by key,
+// lock of a key exists only while it is held or awaited
+type KeyedLock[K comparable] struct {
+	mu    sync.Mutex
+	locks map[K]*keyedEntry
+}
+
+type keyedEntry struct {
+	lock Lock
+	refs int
+}
+
+func NewKeyedLock[K comparable]() *KeyedLock[K] {
+	return &KeyedLock[K]{locks: map[K]*keyedEntry{}}
+}
+
+// acquire returns entry of key and increments its reference count
+func (k *KeyedLock[K]) acquire(key K) *keyedEntry {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.locks == nil {
+		k.locks = map[K]*keyedEntry{}
+	}
+	e, ok := k.locks[key]
+	if !ok {
+		e = &keyedEntry{}
+		k.locks[key] = e
+	}
+	e.refs++
+	return e
+}
+
+// release decrements reference count and removes unused entry
+func (k *KeyedLock[K]) release(key K) *keyedEntry {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	e, ok := k.locks[key]
+	if !ok {
+		panic("utils: unlock of unlocked key")
+	}
+	e.refs--
+	if e.refs == 0 {
+		delete(k.locks, key)
+	}
+	return e
+}
+
+func (k *KeyedLock[K]) Lock(key K) {
+	k.acquire(key).lock.Lock()
+}
+
+func (k *KeyedLock[K]) Unlock(key K) {
+	k.release(key).lock.Unlock()
+}
+
+func (k *KeyedLock[K]) RLock(key K) {
+	k.acquire(key).lock.RLock()
+}
+
+func (k *KeyedLock[K]) RUnlock(key K) {
+	k.release(key).lock.RUnlock()
+}
+
+// TryLock locks key without waiting, returns false if key is locked
+func (k *KeyedLock[K]) TryLock(key K) bool {
+	if k.acquire(key).lock.TryLock() {
+		return true
+	}
+	k.release(key)
+	return false
+}
+
+// LockContext locks key, returns ctx error if ctx is done before lock is acquired
+func (k *KeyedLock[K]) LockContext(ctx context.Context, key K) error {
+	e := k.acquire(key)
+	if err := e.lock.LockContext(ctx); err != nil {
+		k.release(key)
+		return err
+	}
+	return nil
+}
+
+// Len returns number of keys which are locked or awaited
+func (k *KeyedLock[K]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.locks)
+}
@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeyedLock(t *testing.T) {
+	k := NewKeyedLock[string]()
+
+	var wg sync.WaitGroup
+	counters := map[string]int{}
+	for i := 0; i < 100; i++ {
+		key := []string{"a", "b"}[i%2]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			k.Lock(key)
+			defer k.Unlock(key)
+			counters[key]++
+		}()
+	}
+	wg.Wait()
+
+	if counters["a"] != 50 || counters["b"] != 50 {
+		t.Errorf("unexpected counters %v", counters)
+	}
+	if k.Len() != 0 {
+		t.Errorf("%d keys not cleaned up", k.Len())
+	}
+}
+
+func TestKeyedLockTry(t *testing.T) {
+	k := NewKeyedLock[int]()
+	k.Lock(1)
+
+	if k.TryLock(1) || !k.TryLock(2) {
+		t.Fail()
+	}
+	k.Unlock(2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := k.LockContext(ctx, 1); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	// canceled caller releases its reference right away
+	if k.Len() != 1 {
+		t.Errorf("expected 1 key, got %d", k.Len())
+	}
+
+	k.Unlock(1)
+	if k.Len() != 0 {
+		t.Errorf("%d keys not cleaned up", k.Len())
+	}
+	if !k.TryLock(1) {
+		t.Error("key locked by canceled caller")
+	}
+}
+
+func TestSingleFlight(t *testing.T) {
+	g := NewSingleFlight[string, int]()
+
+	var calls int32
+	release := make(chan struct{})
+	fn := func() (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 7, nil
+	}
+
+	var wg sync.WaitGroup
+	var shared int32
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err, s := g.Do("a", fn)
+			if v != 7 || err != nil {
+				t.Errorf("unexpected result %v %v", v, err)
+			}
+			if s {
+				atomic.AddInt32(&shared, 1)
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 || shared != 5 {
+		t.Errorf("%d calls, %d shared", calls, shared)
+	}
+}
+
+func TestSingleFlightContext(t *testing.T) {
+	g := NewSingleFlight[string, int]()
+
+	canceled := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err, _ := g.DoContext(ctx, "a", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		close(canceled)
+		return 0, ctx.Err()
+	})
+	if err != context.Canceled {
+		t.Errorf("expected canceled, got %v", err)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("fn ctx not canceled after all callers left")
+	}
+}
+
+func TestSingleFlightForget(t *testing.T) {
+	g := NewSingleFlight[string, int]()
+
+	release := make(chan struct{})
+	go g.Do("a", func() (int, error) {
+		<-release
+		return 1, nil
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	g.Forget("a")
+	v, _, shared := g.Do("a", func() (int, error) { return 2, nil })
+	if v != 2 || shared {
+		t.Errorf("forgotten call reused, %v", v)
+	}
+	close(release)
+
+	errX := errors.New("x")
+	if _, err, _ := g.Do("b", func() (int, error) { return 0, errX }); err != errX {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err, _ := g.Do("c", func() (int, error) { panic("boom") }); err == nil {
+		t.Error("panic not returned as error")
+	}
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Election elects a single leader among processes on the local machine using FileLock.
+// Leader refreshes its lease every lease/3, contenders break lock not refreshed for lease.
+type Election struct {
+	lock  *FileLock
+	lease time.Duration
+
+	mu        sync.Mutex
+	leader    bool
+	onElected func(ctx context.Context)
+	onRevoked func()
+}
+
+func NewElection(path string, lease time.Duration) *Election {
+	return &Election{
+		lock:  NewFileLock(path).BreakStale(lease),
+		lease: lease,
+	}
+}
+
+// OnElected sets function called when this process becomes leader,
+// its ctx is canceled when leadership is revoked
+func (e *Election) OnElected(fn func(ctx context.Context)) *Election {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onElected = fn
+	return e
+}
+
+// OnRevoked sets function called when this process stops being leader
+func (e *Election) OnRevoked(fn func()) *Election {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onRevoked = fn
+	return e
+}
+
+func (e *Election) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// Run campaigns for leadership until ctx is done, leadership is resigned then.
+// When lease can't be refreshed leadership is revoked and campaign starts again.
+func (e *Election) Run(ctx context.Context) error {
+	for {
+		if err := e.lock.Lock(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		err := e.lead(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil && err != ErrLockLost {
+			return err
+		}
+	}
+}
+
+// lead holds leadership until ctx is done or lease is lost
+func (e *Election) lead(ctx context.Context) error {
+	leaderCtx, cancel := context.WithCancel(ctx)
+
+	e.mu.Lock()
+	e.leader = true
+	onElected, onRevoked := e.onElected, e.onRevoked
+	e.mu.Unlock()
+
+	if onElected != nil {
+		go onElected(leaderCtx)
+	}
+
+	defer func() {
+		cancel()
+		e.mu.Lock()
+		e.leader = false
+		e.mu.Unlock()
+
+		if onRevoked != nil {
+			onRevoked()
+		}
+	}()
+
+	interval := e.lease / 3
+	if interval <= 0 {
+		interval = time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return e.lock.Unlock()
+		case <-t.C:
+			if err := e.lock.Refresh(); err != nil {
+				e.lock.Unlock()
+				return err
+			}
+		}
+	}
+}
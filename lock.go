@@ -1,31 +1,182 @@
 package utils
 
-import "sync"
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 type Lock struct {
 	lock sync.RWMutex
+
+	// assigned on first use in debug mode
+	id    uint64
+	stats lockStats
+}
+
+type lockStats struct {
+	acquisitions atomic.Int64
+	contended    atomic.Int64
+	wait         atomic.Int64
+}
+
+// LockStats are collected only while lock debug mode is enabled
+type LockStats struct {
+	Acquisitions int64
+	// number of acquisitions which had to wait
+	Contended int64
+	WaitTime  time.Duration
 }
 
 func (l *Lock) Lock() {
-	if l != nil {
-		l.lock.Lock()
+	if l == nil {
+		return
+	}
+	if d := lockDebug.Load(); d != nil {
+		d.acquire(l, true)
+		return
 	}
+	l.lock.Lock()
 }
 
 func (l *Lock) Unlock() {
-	if l != nil {
-		l.lock.Unlock()
+	if l == nil {
+		return
 	}
+	if d := lockDebug.Load(); d != nil {
+		d.release(l, true)
+	}
+	l.lock.Unlock()
 }
 
 func (l *Lock) RLock() {
-	if l != nil {
-		l.lock.RLock()
+	if l == nil {
+		return
+	}
+	if d := lockDebug.Load(); d != nil {
+		d.acquire(l, false)
+		return
 	}
+	l.lock.RLock()
 }
 
 func (l *Lock) RUnlock() {
-	if l != nil {
-		l.lock.RUnlock()
+	if l == nil {
+		return
+	}
+	if d := lockDebug.Load(); d != nil {
+		d.release(l, false)
+	}
+	l.lock.RUnlock()
+}
+
+func (l *Lock) try(write bool) bool {
+	if write {
+		return l.lock.TryLock()
+	}
+	return l.lock.TryRLock()
+}
+
+// TryLock locks without waiting, returns false if lock is held
+func (l *Lock) TryLock() bool {
+	if l == nil {
+		return true
+	}
+	if d := lockDebug.Load(); d != nil {
+		return d.try(l, true)
+	}
+	return l.lock.TryLock()
+}
+
+// TryRLock read locks without waiting, returns false if write lock is held or awaited
+func (l *Lock) TryRLock() bool {
+	if l == nil {
+		return true
+	}
+	if d := lockDebug.Load(); d != nil {
+		return d.try(l, false)
+	}
+	return l.lock.TryRLock()
+}
+
+// LockContext locks, returns ctx error if ctx is done before lock is acquired
+func (l *Lock) LockContext(ctx context.Context) error {
+	return l.lockContext(ctx, true)
+}
+
+// RLockContext read locks, returns ctx error if ctx is done before lock is acquired
+func (l *Lock) RLockContext(ctx context.Context) error {
+	return l.lockContext(ctx, false)
+}
+
+func (l *Lock) lockContext(ctx context.Context, write bool) error {
+	if l == nil {
+		return nil
+	}
+	if d := lockDebug.Load(); d != nil {
+		return d.acquireContext(ctx, l, write)
+	}
+	if l.try(write) {
+		return nil
+	}
+	return l.wait(ctx, write)
+}
+
+// LockTimeout locks, returns context.DeadlineExceeded if lock is not acquired within timeout
+func (l *Lock) LockTimeout(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return l.LockContext(ctx)
+}
+
+// wait locks in new goroutine until lock is acquired or ctx is done.
+// Waiting writer blocks new readers like in Lock, so it is not starved by them.
+// If ctx wins, the goroutine keeps waiting and unlocks right after it acquires the lock.
+func (l *Lock) wait(ctx context.Context, write bool) error {
+	acquired := make(chan struct{})
+	go func() {
+		if write {
+			l.lock.Lock()
+		} else {
+			l.lock.RLock()
+		}
+
+		select {
+		case acquired <- struct{}{}:
+		case <-ctx.Done():
+			if write {
+				l.lock.Unlock()
+			} else {
+				l.lock.RUnlock()
+			}
+		}
+	}()
+
+	select {
+	case <-acquired:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns contention stats, zero for nil lock
+func (l *Lock) Stats() LockStats {
+	if l == nil {
+		return LockStats{}
+	}
+	return LockStats{
+		Acquisitions: l.stats.acquisitions.Load(),
+		Contended:    l.stats.contended.Load(),
+		WaitTime:     time.Duration(l.stats.wait.Load()),
+	}
+}
+
+// ID returns lock id used in debug reports, it is assigned on first use in debug mode
+func (l *Lock) ID() uint64 {
+	if l == nil {
+		return 0
 	}
+	return atomic.LoadUint64(&l.id)
 }
@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func collectReports(threshold time.Duration) (func() []LockReport, func()) {
+	var mu sync.Mutex
+	var reports []LockReport
+
+	EnableLockDebug(LockDebug{
+		HoldThreshold: threshold,
+		OnReport: func(r LockReport) {
+			mu.Lock()
+			reports = append(reports, r)
+			mu.Unlock()
+		},
+	})
+
+	get := func() []LockReport {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]LockReport(nil), reports...)
+	}
+	return get, DisableLockDebug
+}
+
+func TestLockStats(t *testing.T) {
+	_, disable := collectReports(0)
+	defer disable()
+
+	l := &Lock{}
+	l.Lock()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		l.Unlock()
+	}()
+	l.RLock()
+	l.RUnlock()
+
+	stats := l.Stats()
+	if stats.Acquisitions != 2 || stats.Contended != 1 || stats.WaitTime < 5*time.Millisecond {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// contention and wait time are counted once per LockContext call
+	l.Lock()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		l.Unlock()
+	}()
+	if err := l.LockTimeout(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	l.Unlock()
+
+	stats = l.Stats()
+	if stats.Acquisitions != 4 || stats.Contended != 2 || stats.WaitTime < 20*time.Millisecond {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if held := HeldLocks(); len(held) != 0 {
+		t.Errorf("unexpected held locks %+v", held)
+	}
+
+	var nilLock *Lock
+	nilLock.Lock()
+	nilLock.Unlock()
+	if nilLock.Stats() != (LockStats{}) {
+		t.Fail()
+	}
+}
+
+func TestLockLongHold(t *testing.T) {
+	reports, disable := collectReports(10 * time.Millisecond)
+	defer disable()
+
+	l := &Lock{}
+	l.Lock()
+	if held := HeldLocks(); len(held) != 1 || held[0].LockID != l.ID() || !held[0].Write {
+		t.Errorf("unexpected holders %+v", held)
+	}
+
+	// reported by watchdog while still held
+	time.Sleep(30 * time.Millisecond)
+	if r := reports(); len(r) != 1 || r[0].Kind != LongHold || r[0].LockID != l.ID() {
+		t.Errorf("unexpected reports %+v", r)
+	}
+
+	l.Unlock()
+	if len(reports()) != 1 || len(HeldLocks()) != 0 {
+		t.Error("long hold reported twice")
+	}
+}
+
+func TestLockOrderInversion(t *testing.T) {
+	reports, disable := collectReports(0)
+	defer disable()
+
+	a, b := &Lock{}, &Lock{}
+
+	a.Lock()
+	b.Lock()
+	b.Unlock()
+	a.Unlock()
+
+	b.RLock()
+	a.RLock()
+	a.RUnlock()
+	b.RUnlock()
+
+	r := reports()
+	if len(r) != 1 || r[0].Kind != OrderInversion || r[0].LockID != a.ID() || r[0].HeldID != b.ID() {
+		t.Errorf("unexpected reports %+v", r)
+	}
+}
+
+func TestTryLock(t *testing.T) {
+	l := &Lock{}
+	if !l.TryRLock() || !l.TryRLock() || l.TryLock() {
+		t.Error("read locks should be shared")
+	}
+	l.RUnlock()
+	l.RUnlock()
+
+	if !l.TryLock() || l.TryRLock() || l.TryLock() {
+		t.Error("write lock should be exclusive")
+	}
+	l.Unlock()
+
+	var nilLock *Lock
+	if !nilLock.TryLock() || nilLock.LockContext(context.Background()) != nil {
+		t.Fail()
+	}
+}
+
+func TestLockContext(t *testing.T) {
+	l := &Lock{}
+	l.Lock()
+
+	if err := l.LockTimeout(10 * time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.RLockContext(ctx); err != context.Canceled {
+		t.Errorf("expected canceled, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		l.Unlock()
+	}()
+	if err := l.LockContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	l.Unlock()
+
+	// timed out attempts don't hold the lock
+	if err := l.LockTimeout(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	l.Unlock()
+}
+
+func TestLockContextNoLeak(t *testing.T) {
+	l := &Lock{}
+	l.RLock()
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		if err := l.LockTimeout(time.Millisecond); err != context.DeadlineExceeded {
+			t.Fatalf("expected deadline exceeded, got %v", err)
+		}
+	}
+
+	// timed out writers wait for the lock and release it at once
+	l.RUnlock()
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("goroutines left after timeouts, %d before, %d after", before, after)
+	}
+	if !l.TryLock() {
+		t.Error("lock held by timed out writer")
+	}
+	l.Unlock()
+}
+
+func TestLockContextReaders(t *testing.T) {
+	l := &Lock{}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	// overlapping readers keep the lock read locked all the time
+	l.RLock()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				l.RLock()
+				time.Sleep(time.Millisecond)
+				l.RUnlock()
+			}
+		}()
+	}
+	time.Sleep(5 * time.Millisecond)
+	l.RUnlock()
+
+	if err := l.LockTimeout(time.Second); err != nil {
+		t.Errorf("writer starved by readers: %v", err)
+	} else {
+		l.Unlock()
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,330 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type LockReportKind uint8
+
+const (
+	// lock was held longer than HoldThreshold
+	LongHold LockReportKind = iota + 1
+	// two locks were acquired in opposite order by different code paths
+	OrderInversion
+)
+
+func (k LockReportKind) String() string {
+	switch k {
+	case LongHold:
+		return "long hold"
+	case OrderInversion:
+		return "lock order inversion"
+	}
+	return "unknown"
+}
+
+type LockReport struct {
+	Kind   LockReportKind
+	LockID uint64
+	// for OrderInversion the lock which was already held
+	HeldID uint64
+	Held   time.Duration
+	// stack of the holder, for OrderInversion stack of the current acquisition
+	Stack string
+	// for OrderInversion stack where the opposite order was seen first
+	OtherStack string
+}
+
+type LockHolder struct {
+	LockID    uint64
+	Goroutine uint64
+	Write     bool
+	Since     time.Time
+	Stack     string
+}
+
+type LockDebug struct {
+	// report locks held longer than HoldThreshold, disabled if <= 0
+	HoldThreshold time.Duration
+	// called for every report, reports are logged if nil
+	OnReport func(r LockReport)
+}
+
+var (
+	lockDebug  atomic.Pointer[lockDebugState]
+	lockLastID uint64
+)
+
+type holder struct {
+	LockHolder
+	lock     *Lock
+	reported bool
+}
+
+type lockDebugState struct {
+	opts LockDebug
+	stop chan struct{}
+
+	mu sync.Mutex
+	// locks held by goroutine
+	held map[uint64][]*holder
+	// first stack where lock [1] was acquired while holding lock [0]
+	order map[[2]uint64]string
+	// inversions already reported
+	inverted map[[2]uint64]bool
+}
+
+// EnableLockDebug turns on debug mode for all locks. It records lock holders,
+// reports long holds and lock order inversions and collects contention stats.
+// It is expensive, every acquisition captures goroutine stack.
+func EnableLockDebug(opts LockDebug) {
+	d := &lockDebugState{
+		opts:     opts,
+		stop:     make(chan struct{}),
+		held:     map[uint64][]*holder{},
+		order:    map[[2]uint64]string{},
+		inverted: map[[2]uint64]bool{},
+	}
+	if old := lockDebug.Swap(d); old != nil {
+		close(old.stop)
+	}
+
+	if opts.HoldThreshold > 0 {
+		go d.watch()
+	}
+}
+
+// DisableLockDebug turns off lock debug mode
+func DisableLockDebug() {
+	if old := lockDebug.Swap(nil); old != nil {
+		close(old.stop)
+	}
+}
+
+// HeldLocks returns currently held locks, it is empty when debug mode is disabled
+func HeldLocks() []LockHolder {
+	d := lockDebug.Load()
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	out := []LockHolder{}
+	for _, hs := range d.held {
+		for _, h := range hs {
+			out = append(out, h.LockHolder)
+		}
+	}
+	return out
+}
+
+// goroutine returns id and stack of the current goroutine
+func goroutine() (uint64, string) {
+	buf := make([]byte, 4096)
+	buf = buf[:runtime.Stack(buf, false)]
+
+	// first line is "goroutine 123 [running]:"
+	line := buf
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := bytes.Fields(line)
+	var id uint64
+	if len(fields) > 1 {
+		id, _ = strconv.ParseUint(string(fields[1]), 10, 64)
+	}
+	return id, string(buf)
+}
+
+func (d *lockDebugState) report(r LockReport) {
+	if d.opts.OnReport != nil {
+		d.opts.OnReport(r)
+		return
+	}
+	if r.Kind == OrderInversion {
+		log.Printf("lock %d: %s with lock %d\n%s\nopposite order:\n%s", r.LockID, r.Kind, r.HeldID, r.Stack, r.OtherStack)
+		return
+	}
+	log.Printf("lock %d: %s, held %s\n%s", r.LockID, r.Kind, r.Held, r.Stack)
+}
+
+// prepare assigns lock id and checks lock order before acquisition,
+// so inversion is reported even if acquisition deadlocks
+func (d *lockDebugState) prepare(l *Lock) (id, gid uint64, stack string) {
+	if atomic.LoadUint64(&l.id) == 0 {
+		atomic.CompareAndSwapUint64(&l.id, 0, atomic.AddUint64(&lockLastID, 1))
+	}
+	id = atomic.LoadUint64(&l.id)
+	gid, stack = goroutine()
+
+	var reports []LockReport
+	d.mu.Lock()
+	for _, h := range d.held[gid] {
+		if h.LockID == id {
+			continue
+		}
+		if other, ok := d.order[[2]uint64{id, h.LockID}]; ok && !d.inverted[[2]uint64{id, h.LockID}] {
+			d.inverted[[2]uint64{id, h.LockID}] = true
+			reports = append(reports, LockReport{
+				Kind:       OrderInversion,
+				LockID:     id,
+				HeldID:     h.LockID,
+				Stack:      stack,
+				OtherStack: other,
+			})
+		}
+		if _, ok := d.order[[2]uint64{h.LockID, id}]; !ok {
+			d.order[[2]uint64{h.LockID, id}] = stack
+		}
+	}
+	d.mu.Unlock()
+
+	for _, r := range reports {
+		d.report(r)
+	}
+	return id, gid, stack
+}
+
+// hold records acquired lock
+func (d *lockDebugState) hold(l *Lock, id, gid uint64, stack string, write bool, start time.Time) {
+	now := time.Now()
+	l.stats.acquisitions.Add(1)
+	l.stats.wait.Add(int64(now.Sub(start)))
+
+	d.mu.Lock()
+	d.held[gid] = append(d.held[gid], &holder{
+		LockHolder: LockHolder{LockID: id, Goroutine: gid, Write: write, Since: now, Stack: stack},
+		lock:       l,
+	})
+	d.mu.Unlock()
+}
+
+func (d *lockDebugState) acquire(l *Lock, write bool) {
+	id, gid, stack := d.prepare(l)
+
+	start := time.Now()
+	if !l.try(write) {
+		l.stats.contended.Add(1)
+		if write {
+			l.lock.Lock()
+		} else {
+			l.lock.RLock()
+		}
+	}
+	d.hold(l, id, gid, stack, write, start)
+}
+
+// acquireContext counts contention and wait time once per call, not per wait attempt
+func (d *lockDebugState) acquireContext(ctx context.Context, l *Lock, write bool) error {
+	id, gid, stack := d.prepare(l)
+
+	start := time.Now()
+	if !l.try(write) {
+		l.stats.contended.Add(1)
+		if err := l.wait(ctx, write); err != nil {
+			return err
+		}
+	}
+	d.hold(l, id, gid, stack, write, start)
+	return nil
+}
+
+func (d *lockDebugState) try(l *Lock, write bool) bool {
+	id, gid, stack := d.prepare(l)
+
+	start := time.Now()
+	if !l.try(write) {
+		l.stats.contended.Add(1)
+		return false
+	}
+	d.hold(l, id, gid, stack, write, start)
+	return true
+}
+
+func (d *lockDebugState) release(l *Lock, write bool) {
+	gid, _ := goroutine()
+
+	d.mu.Lock()
+	h := d.remove(gid, l, write)
+	if h == nil {
+		// unlocked by other goroutine than the one which locked it
+		for g := range d.held {
+			if h = d.remove(g, l, write); h != nil {
+				break
+			}
+		}
+	}
+	d.mu.Unlock()
+
+	if h == nil || h.reported || d.opts.HoldThreshold <= 0 {
+		return
+	}
+	if held := time.Since(h.Since); held > d.opts.HoldThreshold {
+		d.report(LockReport{Kind: LongHold, LockID: h.LockID, Held: held, Stack: h.Stack})
+	}
+}
+
+// remove removes the last matching holder of goroutine, d.mu must be held
+func (d *lockDebugState) remove(gid uint64, l *Lock, write bool) *holder {
+	hs := d.held[gid]
+	for i := len(hs) - 1; i >= 0; i-- {
+		if hs[i].lock != l || hs[i].Write != write {
+			continue
+		}
+		h := hs[i]
+		hs = append(hs[:i], hs[i+1:]...)
+		if len(hs) == 0 {
+			delete(d.held, gid)
+		} else {
+			d.held[gid] = hs
+		}
+		return h
+	}
+	return nil
+}
+
+// watch reports locks which are still held after threshold, e.g. in deadlock
+func (d *lockDebugState) watch() {
+	interval := d.opts.HoldThreshold / 2
+	if interval <= 0 {
+		interval = d.opts.HoldThreshold
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-t.C:
+		}
+
+		var reports []LockReport
+		now := time.Now()
+
+		d.mu.Lock()
+		for _, hs := range d.held {
+			for _, h := range hs {
+				if h.reported || now.Sub(h.Since) <= d.opts.HoldThreshold {
+					continue
+				}
+				h.reported = true
+				reports = append(reports, LockReport{Kind: LongHold, LockID: h.LockID, Held: now.Sub(h.Since), Stack: h.Stack})
+			}
+		}
+		d.mu.Unlock()
+
+		for _, r := range reports {
+			d.report(r)
+		}
+	}
+}
@@ -63,18 +63,67 @@ func (b Bucket[V]) Iter() types.Iterator[string, V] {
 	return out
 }
 
+// return unbuffered iterator which stops when ctx is done,
+// map read lock is held until iterator is drained or ctx is done
+func (b Bucket[V]) IterContext(ctx context.Context) types.Iterator[string, V] {
+	out := make(chan types.Item[string, V])
+	go func() {
+		defer close(out)
+		for item := range b.m.IterContext(ctx) {
+			if !strings.HasPrefix(item.Key, b.pfx) {
+				continue
+			}
+
+			select {
+			case out <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// range over bucket until fn returns false
+func (b Bucket[V]) Range(fn func(k string, v V) bool) {
+	b.m.Range(func(k string, v V) bool {
+		if !strings.HasPrefix(k, b.pfx) {
+			return true
+		}
+		return fn(k, v)
+	})
+}
+
+// range over bucket until fn returns false or ctx is done,
+// return ctx error if interrupted by ctx
+func (b Bucket[V]) RangeContext(ctx context.Context, fn func(k string, v V) bool) error {
+	return b.m.RangeContext(ctx, func(k string, v V) bool {
+		if !strings.HasPrefix(k, b.pfx) {
+			return true
+		}
+		return fn(k, v)
+	})
+}
+
 func (b Bucket[V]) Watch(ctx context.Context) types.Watcher[string, V] {
 	out := make(chan types.WatchMsg[string, V])
 	go func() {
+		defer close(out)
 		for event := range b.m.Hub.Register(ctx) {
-			if strings.HasPrefix(event.Key, b.pfx) {
-				out <- types.WatchMsg[string, V]{
-					Event: event.Event,
-					Item: types.Item[string, V]{
-						Key:   event.Key,
-						Value: event.Value,
-					},
-				}
+			if !strings.HasPrefix(event.Key, b.pfx) {
+				continue
+			}
+			select {
+			case out <- types.WatchMsg[string, V]{
+				Event: event.Event,
+				Item: types.Item[string, V]{
+					Key:   event.Key,
+					Value: event.Value,
+				},
+			}:
+			case <-ctx.Done():
+				return
 			}
 		}
 	}()
@@ -83,9 +132,10 @@ func (b Bucket[V]) Watch(ctx context.Context) types.Watcher[string, V] {
 }
 
 func (b Bucket[V]) ForEach(fn func(k string, v V)) {
-	for item := range b.Iter() {
-		fn(item.Key, item.Value)
-	}
+	b.Range(func(k string, v V) bool {
+		fn(k, v)
+		return true
+	})
 }
 
 func (b Bucket[V]) Keys() (keys []string) {
@@ -22,6 +22,13 @@ func New[K comparable, V any](data map[K]V) *Map[K, V] {
 	return &Map[K, V]{data: data}
 }
 
+func closedIter[K comparable, V any]() types.Iterator[K, V] {
+	iter := make(chan types.Item[K, V])
+	close(iter)
+	return iter
+}
+
+// init must be called with lock held
 func (m *Map[K, V]) init() error {
 	if m == nil {
 		return types.ErrNilMap
@@ -95,62 +102,150 @@ func (m *Map[K, V]) GetFull(k K) (obj V, exists bool) {
 
 // set value for key
 func (m *Map[K, V]) Set(k K, v V) {
-	if m.readonly || m.init() != nil {
-		return
-	}
-	if m.Hub != nil {
-		m.Hub.Broadcast(types.WatchMsg[K, V]{
-			Event: types.PutEvent,
-			Item: types.Item[K, V]{
-				Key:   k,
-				Value: v,
-			},
-		})
+	m.SetE(k, v)
+}
+
+// set value for key, return ErrNilMap or ErrReadOnlyMap
+func (m *Map[K, V]) SetE(k K, v V) error {
+	if err := m.writable(); err != nil {
+		return err
 	}
 
 	m.lock.Lock()
-	defer m.lock.Unlock()
+	m.set(k, v)
+	m.lock.Unlock()
 
-	m.data[k] = v
+	m.publish(types.PutEvent, k, v)
+	return nil
+}
+
+// set value for key, return ctx error if lock is not acquired before ctx is done
+func (m *Map[K, V]) SetContext(ctx context.Context, k K, v V) error {
+	if err := m.writable(); err != nil {
+		return err
+	}
+
+	if err := m.lock.LockContext(ctx); err != nil {
+		return err
+	}
+	m.set(k, v)
+	m.lock.Unlock()
+
+	m.publish(types.PutEvent, k, v)
+	return nil
 }
 
 // delete key from Map
 func (m *Map[K, V]) Delete(k K) {
-	if m == nil || m.readonly {
-		return
-	}
-	if m.Hub != nil {
-		m.Hub.Broadcast(types.WatchMsg[K, V]{
-			Event: types.DeleteEvent,
-			Item: types.Item[K, V]{
-				Key:   k,
-				Value: m.Get(k),
-			},
-		})
+	m.DeleteE(k)
+}
+
+// delete key from Map, return ErrNilMap or ErrReadOnlyMap
+func (m *Map[K, V]) DeleteE(k K) error {
+	if err := m.writable(); err != nil {
+		return err
 	}
 
 	m.lock.Lock()
-	defer m.lock.Unlock()
+	v := m.delete(k)
+	m.lock.Unlock()
 
-	delete(m.data, k)
+	m.publish(types.DeleteEvent, k, v)
+	return nil
+}
+
+// delete key from Map, return ctx error if lock is not acquired before ctx is done
+func (m *Map[K, V]) DeleteContext(ctx context.Context, k K) error {
+	if err := m.writable(); err != nil {
+		return err
+	}
+
+	if err := m.lock.LockContext(ctx); err != nil {
+		return err
+	}
+	v := m.delete(k)
+	m.lock.Unlock()
+
+	m.publish(types.DeleteEvent, k, v)
+	return nil
 }
 
 // run function with direct access to Map
 func (m *Map[K, V]) Commit(fn func(data map[K]V)) {
-	if m.readonly || m.init() != nil {
-		return
+	m.CommitE(fn)
+}
+
+// run function with direct access to Map, return ErrNilMap or ErrReadOnlyMap
+func (m *Map[K, V]) CommitE(fn func(data map[K]V)) error {
+	if err := m.writable(); err != nil {
+		return err
 	}
 
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
+	m.init()
 	fn(m.data)
+	return nil
+}
+
+// run function with direct access to Map, return ctx error if lock is not acquired before ctx is done
+func (m *Map[K, V]) CommitContext(ctx context.Context, fn func(data map[K]V)) error {
+	if err := m.writable(); err != nil {
+		return err
+	}
+
+	if err := m.lock.LockContext(ctx); err != nil {
+		return err
+	}
+	defer m.lock.Unlock()
+
+	m.init()
+	fn(m.data)
+	return nil
+}
+
+// return ErrNilMap or ErrReadOnlyMap if Map can't be modified
+func (m *Map[K, V]) writable() error {
+	if m == nil {
+		return types.ErrNilMap
+	}
+	if m.readonly {
+		return types.ErrReadOnlyMap
+	}
+	return nil
+}
+
+// set value for key, lock must be held
+func (m *Map[K, V]) set(k K, v V) {
+	m.init()
+	m.data[k] = v
+}
+
+// delete key and return its value, lock must be held
+func (m *Map[K, V]) delete(k K) V {
+	v := m.data[k]
+	delete(m.data, k)
+	return v
+}
+
+// broadcast event if Map is eventfull
+func (m *Map[K, V]) publish(event types.EventType, k K, v V) {
+	if m.Hub != nil {
+		m.Hub.Broadcast(types.WatchMsg[K, V]{
+			Event: event,
+			Item: types.Item[K, V]{
+				Key:   k,
+				Value: v,
+			},
+		})
+	}
 }
 
 // return iterator for safe iterating over Map
 func (m *Map[K, V]) Iter() types.Iterator[K, V] {
 	if m == nil {
-		return nil
+		return closedIter[K, V]()
 	}
 
 	m.lock.RLock()
@@ -181,6 +276,69 @@ func (m *Map[K, V]) ForEach(fn func(k K, v V)) {
 	}
 }
 
+// return unbuffered iterator which stops when ctx is done,
+// read lock is held until iterator is drained or ctx is done
+func (m *Map[K, V]) IterContext(ctx context.Context) types.Iterator[K, V] {
+	if m == nil {
+		return closedIter[K, V]()
+	}
+
+	m.lock.RLock()
+	iter := make(chan types.Item[K, V])
+
+	go func() {
+		defer close(iter)
+		defer m.lock.RUnlock()
+		for k, v := range m.data {
+			select {
+			case iter <- types.Item[K, V]{Key: k, Value: v}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return iter
+}
+
+// range over Map until fn returns false
+func (m *Map[K, V]) Range(fn func(k K, v V) bool) {
+	if m == nil {
+		return
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	for k, v := range m.data {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+// range over Map until fn returns false or ctx is done,
+// return ctx error if interrupted by ctx
+func (m *Map[K, V]) RangeContext(ctx context.Context, fn func(k K, v V) bool) error {
+	if m == nil {
+		return nil
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	for k, v := range m.data {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !fn(k, v) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
 // return all Map keys
 func (m *Map[K, V]) Keys() (keys []K) {
 	if m == nil {
@@ -228,20 +386,34 @@ func (m *Map[K, V]) Len() int {
 	return len(m.data)
 }
 
-// return Map copy
+// return lock contention stats, collected only in lock debug mode
+func (m *Map[K, V]) LockStats() utils.LockStats {
+	if m == nil {
+		return utils.LockStats{}
+	}
+	return m.lock.Stats()
+}
+
+// return Map copy, nil if it can't be copied
 func (m *Map[K, V]) Copy() *Map[K, V] {
+	copy, _ := m.CopyE()
+	return copy
+}
+
+// return Map copy, ErrNilMap or error if data can't be copied
+func (m *Map[K, V]) CopyE() (*Map[K, V], error) {
 	if m == nil {
-		return nil
+		return nil, types.ErrNilMap
 	}
 
 	m.lock.RLock()
 	defer m.lock.RUnlock()
 
-	copy := utils.DeepCopy(m.data)
-	if copy == nil {
-		return nil
+	copy, err := utils.DeepCopyE(m.data)
+	if err != nil {
+		return nil, err
 	}
-	return New(*copy)
+	return New(*copy), nil
 }
 
 func (m *Map[K, V]) marshal(marsh types.MarshalFunc) ([]byte, error) {
@@ -252,10 +424,9 @@ func (m *Map[K, V]) marshal(marsh types.MarshalFunc) ([]byte, error) {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
 
-	if err := m.init(); err != nil {
-		return nil, err
+	if m.data == nil {
+		return marsh(map[K]V{})
 	}
-
 	return marsh(m.data)
 }
 
@@ -268,12 +439,9 @@ func (m *Map[K, V]) unmarshal(unmarsh types.UnmarshalFunc, data []byte) error {
 		return types.ErrReadOnlyMap
 	}
 
-	if err := m.init(); err != nil {
-		return err
-	}
-
 	m.lock.Lock()
 	defer m.lock.Unlock()
+	m.init()
 
 	return unmarsh(data, &m.data)
 }
@@ -1,8 +1,15 @@
 package maps
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
+	"runtime"
 	"testing"
+	"time"
+
+	"github.com/timoni-io/go-utils/errors"
+	"github.com/timoni-io/go-utils/types"
 )
 
 func TestReadOnly(t *testing.T) {
@@ -246,6 +253,18 @@ func TestCopy(t *testing.T) {
 	}
 }
 
+func TestCopyE(t *testing.T) {
+	m := New(map[string]any{"ch": make(chan int)})
+	if cp, err := m.CopyE(); cp != nil || err == nil {
+		t.Errorf("expected copy error, got %v %v", cp, err)
+	}
+
+	var nilMap *Map[string, int]
+	if _, err := nilMap.CopyE(); err != types.ErrNilMap {
+		t.Errorf("expected ErrNilMap, got %v", err)
+	}
+}
+
 func TestMarshalJSON(t *testing.T) {
 	m := New[string, string](nil)
 	if m == nil {
@@ -281,3 +300,162 @@ func TestUnmarshalJSON(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestIterContext(t *testing.T) {
+	m := New(map[string]string{"1": "a", "2": "b", "3": "c"}).Safe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for range m.IterContext(ctx) {
+		break
+	}
+	cancel()
+
+	// lock must be released after cancel
+	m.Set("4", "d")
+	if m.Len() != 4 {
+		t.Fail()
+	}
+}
+
+func TestRange(t *testing.T) {
+	m := New(map[string]string{"1": "a", "2": "b", "3": "c"})
+
+	i := 0
+	m.Range(func(k, v string) bool {
+		i++
+		return false
+	})
+
+	if i != 1 {
+		t.Errorf("range not stopped, %d calls", i)
+	}
+}
+
+func TestBucketRange(t *testing.T) {
+	m := New(map[string]string{"a/1": "a", "a/2": "b", "b/1": "c"})
+	b := NewBucket(m, "a/")
+
+	keys := []string{}
+	b.Range(func(k, v string) bool {
+		keys = append(keys, k)
+		return true
+	})
+
+	if len(keys) != 2 {
+		t.Errorf("invalid keys %v", keys)
+	}
+}
+
+func TestBucketWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := New[string, string](nil).Safe().Eventfull(ctx, 10)
+	defer m.Close()
+
+	w := NewBucket(m, "a/").Watch(ctx)
+	for m.Subscribers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	m.Set("b/1", "x")
+	m.Set("a/1", "y")
+
+	if msg := <-w; msg.Key != "a/1" || msg.Value != "y" {
+		t.Errorf("unexpected message %v", msg)
+	}
+
+	cancel()
+	for range w {
+	}
+}
+
+func TestSetContext(t *testing.T) {
+	m := New[string, int](nil).Safe()
+
+	locked := make(chan struct{})
+	release := make(chan struct{})
+	go m.Commit(func(data map[string]int) {
+		close(locked)
+		<-release
+	})
+	<-locked
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := m.SetContext(ctx, "a", 1); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	close(release)
+
+	if err := m.SetContext(context.Background(), "a", 1); err != nil || m.Get("a") != 1 {
+		t.Errorf("value not set, %v", err)
+	}
+	if err := m.ReadOnly().DeleteContext(context.Background(), "a"); err != types.ErrReadOnlyMap {
+		t.Errorf("expected ErrReadOnlyMap, got %v", err)
+	}
+}
+
+func TestSetE(t *testing.T) {
+	var nilMap *Map[string, int]
+	nilMap.Set("a", 1)
+	if err := nilMap.SetE("a", 1); err != types.ErrNilMap {
+		t.Errorf("expected ErrNilMap, got %v", err)
+	}
+
+	m := New[string, int](nil).Safe()
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		go func(i int) {
+			m.Set("a", i)
+			done <- struct{}{}
+		}(i)
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+
+	if err := m.ReadOnly().SetE("b", 1); !errors.Is(err, types.ErrReadOnlyMap) || errors.HTTPStatus(err) != http.StatusPreconditionFailed {
+		t.Errorf("expected ErrReadOnlyMap, got %v", err)
+	}
+	if m.CommitE(func(map[string]int) {}) != types.ErrReadOnlyMap || m.Len() != 1 {
+		t.Error("read only map changed")
+	}
+}
+
+func TestSetBlockedGoroutines(t *testing.T) {
+	m := New(map[string]int{}).Safe()
+
+	release := make(chan struct{})
+	locked := make(chan struct{})
+	go m.Commit(func(map[string]int) {
+		close(locked)
+		<-release
+	})
+	<-locked
+
+	before := runtime.NumGoroutine()
+	done := make(chan struct{})
+	for i := 0; i < 20; i++ {
+		go func() {
+			m.Set("a", 1)
+			done <- struct{}{}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	// blocked Set doesn't start helper goroutines
+	if n := runtime.NumGoroutine() - before; n > 20 {
+		t.Errorf("expected 20 goroutines, got %d", n)
+	}
+	close(release)
+	for i := 0; i < 20; i++ {
+		<-done
+	}
+}
+
+func TestCloseNotEventfull(t *testing.T) {
+	m := New(map[string]int{}).Safe()
+	m.Close()
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,6 +1,7 @@
 package maps
 
 import (
+	"context"
 	"github.com/timoni-io/go-utils"
 	"github.com/timoni-io/go-utils/types"
 	"sort"
@@ -126,7 +127,7 @@ func (m *OrderedMap[K, V]) Commit(fn func(data map[K]V)) {
 // return iterator for safe iterating over Map
 func (m *OrderedMap[K, V]) Iter() types.Iterator[K, V] {
 	if m == nil {
-		return nil
+		return closedIter[K, V]()
 	}
 
 	// sort before returning
@@ -147,6 +148,78 @@ func (m *OrderedMap[K, V]) Iter() types.Iterator[K, V] {
 	return iter
 }
 
+// return unbuffered iterator which stops when ctx is done,
+// read lock is held until iterator is drained or ctx is done
+func (m *OrderedMap[K, V]) IterContext(ctx context.Context) types.Iterator[K, V] {
+	if m == nil {
+		return closedIter[K, V]()
+	}
+
+	// sort before returning
+	m.sort()
+
+	m.lock.RLock()
+	iter := make(chan types.Item[K, V])
+
+	go func() {
+		defer close(iter)
+		defer m.lock.RUnlock()
+		for _, k := range m.sortedKeys {
+			select {
+			case iter <- types.Item[K, V]{Key: k, Value: m.data[k]}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return iter
+}
+
+// range over Map until fn returns false
+func (m *OrderedMap[K, V]) Range(fn func(k K, v V) bool) {
+	if m == nil {
+		return
+	}
+
+	// sort before returning
+	m.sort()
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	for _, k := range m.sortedKeys {
+		if !fn(k, m.data[k]) {
+			return
+		}
+	}
+}
+
+// range over Map until fn returns false or ctx is done,
+// return ctx error if interrupted by ctx
+func (m *OrderedMap[K, V]) RangeContext(ctx context.Context, fn func(k K, v V) bool) error {
+	if m == nil {
+		return nil
+	}
+
+	// sort before returning
+	m.sort()
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	for _, k := range m.sortedKeys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !fn(k, m.data[k]) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
 // range over Map
 func (m *OrderedMap[K, V]) ForEach(fn func(k K, v V)) {
 	if m == nil {
@@ -202,19 +275,25 @@ func (m *OrderedMap[K, V]) Values() (values []V) {
 	return
 }
 
-// return Map copy
+// return Map copy, nil if it can't be copied
 func (m *OrderedMap[K, V]) Copy() *OrderedMap[K, V] {
+	copy, _ := m.CopyE()
+	return copy
+}
+
+// return Map copy, ErrNilMap or error if data can't be copied
+func (m *OrderedMap[K, V]) CopyE() (*OrderedMap[K, V], error) {
 	if m == nil {
-		return nil
+		return nil, types.ErrNilMap
 	}
 
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
-	copy := utils.DeepCopy(m.data)
-	if copy == nil {
-		return nil
+	copy, err := utils.DeepCopyE(m.data)
+	if err != nil {
+		return nil, err
 	}
 
-	return NewOrdered(*copy, nil)
+	return NewOrdered(*copy, nil), nil
 }
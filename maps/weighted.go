@@ -1,6 +1,7 @@
 package maps
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -184,7 +185,7 @@ func (m *WeightedMap[K, V]) Commit(fn func(data map[K]types.Weighted[V])) {
 // return iterator for safe iterating over Map
 func (m *WeightedMap[K, V]) Iter() types.Iterator[K, V] {
 	if m == nil {
-		return nil
+		return closedIter[K, V]()
 	}
 
 	// sort before returning
@@ -243,6 +244,78 @@ func (m *WeightedMap[K, V]) WeightIter() <-chan types.Iterator[K, V] {
 	return weightChan
 }
 
+// return unbuffered iterator which stops when ctx is done,
+// read lock is held until iterator is drained or ctx is done
+func (m *WeightedMap[K, V]) IterContext(ctx context.Context) types.Iterator[K, V] {
+	if m == nil {
+		return closedIter[K, V]()
+	}
+
+	// sort before returning
+	m.sort()
+
+	m.lock.RLock()
+	iter := make(chan types.Item[K, V])
+
+	go func() {
+		defer close(iter)
+		defer m.lock.RUnlock()
+		for _, k := range m.sortedKeys {
+			select {
+			case iter <- types.Item[K, V]{Key: k, Value: m.data[k].Value}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return iter
+}
+
+// range over Map until fn returns false
+func (m *WeightedMap[K, V]) Range(fn func(k K, v V) bool) {
+	if m == nil {
+		return
+	}
+
+	// sort before returning
+	m.sort()
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	for _, k := range m.sortedKeys {
+		if !fn(k, m.data[k].Value) {
+			return
+		}
+	}
+}
+
+// range over Map until fn returns false or ctx is done,
+// return ctx error if interrupted by ctx
+func (m *WeightedMap[K, V]) RangeContext(ctx context.Context, fn func(k K, v V) bool) error {
+	if m == nil {
+		return nil
+	}
+
+	// sort before returning
+	m.sort()
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	for _, k := range m.sortedKeys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !fn(k, m.data[k].Value) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
 // range over Map
 func (m *WeightedMap[K, V]) ForEach(fn func(k K, v V)) {
 	if m == nil {
@@ -298,20 +371,26 @@ func (m *WeightedMap[K, V]) Values() (values []V) {
 	return
 }
 
-// return Map copy
+// return Map copy, nil if it can't be copied
 func (m *WeightedMap[K, V]) Copy() *WeightedMap[K, V] {
+	copy, _ := m.CopyE()
+	return copy
+}
+
+// return Map copy, ErrNilMap or error if data can't be copied
+func (m *WeightedMap[K, V]) CopyE() (*WeightedMap[K, V], error) {
 	if m == nil {
-		return nil
+		return nil, types.ErrNilMap
 	}
 
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
-	copy := utils.DeepCopy(m.data)
-	if copy == nil {
-		return nil
+	copy, err := utils.DeepCopyE(m.data)
+	if err != nil {
+		return nil, err
 	}
-	return NewWeighted(*copy)
+	return NewWeighted(*copy), nil
 }
 
 func (m *WeightedMap[K, V]) marshal(marsh types.MarshalFunc) ([]byte, error) {
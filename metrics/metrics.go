@@ -30,11 +30,11 @@ func init() {
 	}()
 }
 
-func (p PerSec) MarshalJSON() ([]byte, error) {
+func (p *PerSec) MarshalJSON() ([]byte, error) {
 	return []byte(p.String()), nil
 }
 
-func (p PerSec) String() string {
+func (p *PerSec) String() string {
 	return fmt.Sprintf("%.2f", float64(p.Int64.Load())/div)
 }
 
@@ -51,11 +51,11 @@ type Value struct {
 	atomic.Int64
 }
 
-func (v Value) MarshalJSON() ([]byte, error) {
+func (v *Value) MarshalJSON() ([]byte, error) {
 	return []byte(v.String()), nil
 }
 
-func (v Value) String() string {
+func (v *Value) String() string {
 	return fmt.Sprint(v.Load())
 }
 
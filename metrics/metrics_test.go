@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValueMarshal(t *testing.T) {
+	m := struct {
+		Req Value
+		Avg Avg
+	}{Avg: Avg{Num: &Value{}, Div: &Value{}}}
+	m.Req.Add(5)
+	m.Avg.Num.Add(3)
+	m.Avg.Div.Add(2)
+
+	// pointer receivers, so metrics are marshaled through pointer
+	out, _ := json.Marshal(&m)
+	if string(out) != `{"Req":5,"Avg":1.50}` {
+		t.Errorf("unexpected json %s", out)
+	}
+}
+func TestValueAlignment(t *testing.T) {
+	// atomic.Int64 is aligned on 32-bit platforms at any offset
+	m := struct {
+		A   int32
+		Req Value
+	}{}
+	m.Req.Add(1)
+	if m.Req.Load() != 1 {
+		t.Error("invalid value")
+	}
+}
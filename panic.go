@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// PanicError is recovered panic with stack trace of the panicking goroutine
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns panic value if it's an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+var panicHook atomic.Pointer[func(err *PanicError)]
+
+// SetPanicHook sets function called with every panic recovered by this library, nil removes the hook.
+// Hook must be safe for concurrent use.
+func SetPanicHook(fn func(err *PanicError)) {
+	if fn == nil {
+		panicHook.Store(nil)
+		return
+	}
+	panicHook.Store(&fn)
+}
+
+// CapturePanic creates PanicError from recovered value and reports it to panic hook,
+// it must be called from the deferred function to capture stack of the panic
+func CapturePanic(v any) *PanicError {
+	err := &PanicError{Value: v, Stack: debug.Stack()}
+	if hook := panicHook.Load(); hook != nil {
+		(*hook)(err)
+	}
+	return err
+}
+
+// Recover recovers panic into err, it must be deferred directly:
+//
+//	defer utils.Recover(&err)
+//
+// err may be nil if panic should be only reported to panic hook.
+func Recover(err *error) {
+	if r := recover(); r != nil {
+		pe := CapturePanic(r)
+		if err != nil {
+			*err = pe
+		}
+	}
+}
+
+// SafeGo runs fn in new goroutine, panic is reported to panic hook instead of crashing the program
+func SafeGo(fn func()) {
+	go func() {
+		defer Recover(nil)
+		fn()
+	}()
+}
+
+// SafeFunc wraps worker function, panic is returned as PanicError
+func SafeFunc(fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) (err error) {
+		defer Recover(&err)
+		return fn(ctx)
+	}
+}
+
+// RecoverHandler responds with 500 Internal Server Error when next panics,
+// http.ErrAbortHandler is passed through to abort the response
+func RecoverHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if v := recover(); v != nil {
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				CapturePanic(v)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRecover(t *testing.T) {
+	fn := func() (err error) {
+		defer Recover(&err)
+		panic("boom")
+	}
+
+	err := fn()
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "boom" {
+		t.Fatalf("panic not recovered, %v", err)
+	}
+	if !bytes.Contains(pe.Stack, []byte("TestRecover")) {
+		t.Error("stack doesn't contain panicking function")
+	}
+
+	errTest := errors.New("test")
+	err = SafeFunc(func(ctx context.Context) error { panic(errTest) })(context.Background())
+	if !errors.Is(err, errTest) {
+		t.Errorf("panic error not unwrapped, %v", err)
+	}
+}
+
+func TestPanicHook(t *testing.T) {
+	reported := make(chan *PanicError, 1)
+	SetPanicHook(func(err *PanicError) { reported <- err })
+	defer SetPanicHook(nil)
+
+	SafeGo(func() { panic("boom") })
+
+	select {
+	case err := <-reported:
+		if err.Value != "boom" {
+			t.Errorf("unexpected panic value %v", err.Value)
+		}
+	case <-time.After(time.Second):
+		t.Error("panic not reported")
+	}
+}
+
+func TestRecoverHandler(t *testing.T) {
+	h := RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected code %d", w.Code)
+	}
+}
@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// DelayQueue holds items until their time comes
+type DelayQueue[T any] struct {
+	pq *PriorityQueue[T, time.Time]
+}
+
+func NewDelayQueue[T any]() *DelayQueue[T] {
+	return &DelayQueue[T]{
+		pq: NewPriorityQueueFunc[T](func(a, b time.Time) bool { return a.Before(b) }),
+	}
+}
+
+// Put adds value available at given time
+func (q *DelayQueue[T]) Put(v T, at time.Time) *Handle[T, time.Time] {
+	return q.pq.Push(v, at)
+}
+
+// PutAfter adds value available after given duration
+func (q *DelayQueue[T]) PutAfter(v T, d time.Duration) *Handle[T, time.Time] {
+	return q.pq.Push(v, time.Now().Add(d))
+}
+
+// Reschedule changes time at which item becomes available
+func (q *DelayQueue[T]) Reschedule(h *Handle[T, time.Time], at time.Time) error {
+	return q.pq.Update(h, at)
+}
+
+func (q *DelayQueue[T]) Remove(h *Handle[T, time.Time]) error {
+	return q.pq.Remove(h)
+}
+
+// TryTake removes and returns first available item, returns false if none is available yet
+func (q *DelayQueue[T]) TryTake() (v T, ok bool) {
+	h, ok := q.pq.popIf(func(h *Handle[T, time.Time]) bool {
+		return !h.priority.After(time.Now())
+	})
+	if !ok {
+		return
+	}
+	return h.Value, true
+}
+
+// Take removes and returns first item, waits until its time comes or ctx is done
+func (q *DelayQueue[T]) Take(ctx context.Context) (T, error) {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		if v, ok := q.TryTake(); ok {
+			return v, nil
+		}
+
+		var wait <-chan time.Time
+		if _, at, ok := q.pq.Peek(); ok {
+			timer.Reset(time.Until(at))
+			wait = timer.C
+		}
+
+		select {
+		case <-wait:
+		case <-q.pq.notify:
+		case <-ctx.Done():
+			return *new(T), ctx.Err()
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+}
+
+// Len returns number of items including those not available yet
+func (q *DelayQueue[T]) Len() int {
+	return q.pq.Len()
+}
+
+func (q *DelayQueue[T]) Clear() {
+	q.pq.Clear()
+}
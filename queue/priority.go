@@ -0,0 +1,237 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+
+	"github.com/timoni-io/go-utils"
+
+	"golang.org/x/exp/constraints"
+)
+
+var (
+	ErrNilQueue      = errors.New("queue is nil")
+	ErrInvalidHandle = errors.New("handle is not in queue")
+)
+
+// Handle points to item in queue, used to update or remove it
+type Handle[T any, P any] struct {
+	Value    T
+	priority P
+	index    int
+	queue    *PriorityQueue[T, P]
+}
+
+// Priority returns item priority, not safe to call concurrently with Update
+func (h *Handle[T, P]) Priority() P {
+	return h.priority
+}
+
+// heap.Interface implementation, PriorityQueue lock must be held
+type items[T any, P any] struct {
+	list []*Handle[T, P]
+	less func(a, b P) bool
+}
+
+func (h *items[T, P]) Len() int           { return len(h.list) }
+func (h *items[T, P]) Less(i, j int) bool { return h.less(h.list[i].priority, h.list[j].priority) }
+
+func (h *items[T, P]) Swap(i, j int) {
+	h.list[i], h.list[j] = h.list[j], h.list[i]
+	h.list[i].index = i
+	h.list[j].index = j
+}
+
+func (h *items[T, P]) Push(x any) {
+	item := x.(*Handle[T, P])
+	item.index = len(h.list)
+	h.list = append(h.list, item)
+}
+
+func (h *items[T, P]) Pop() any {
+	n := len(h.list) - 1
+	item := h.list[n]
+	h.list[n] = nil
+	h.list = h.list[:n]
+	item.index = -1
+	return item
+}
+
+// PriorityQueue is thread safe heap based queue
+type PriorityQueue[T any, P any] struct {
+	lock   *utils.Lock
+	heap   items[T, P]
+	notify chan struct{}
+}
+
+// NewPriorityQueue creates queue returning items with the lowest priority value first
+func NewPriorityQueue[T any, P constraints.Ordered]() *PriorityQueue[T, P] {
+	return NewPriorityQueueFunc[T](func(a, b P) bool { return a < b })
+}
+
+// NewPriorityQueueFunc creates queue returning items for which less is true first
+func NewPriorityQueueFunc[T any, P any](less func(a, b P) bool) *PriorityQueue[T, P] {
+	return &PriorityQueue[T, P]{
+		lock:   &utils.Lock{},
+		heap:   items[T, P]{less: less},
+		notify: make(chan struct{}, 1),
+	}
+}
+
+func (q *PriorityQueue[T, P]) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Push adds value with priority, returned handle can be used to update or remove it
+func (q *PriorityQueue[T, P]) Push(v T, priority P) *Handle[T, P] {
+	if q == nil {
+		return nil
+	}
+
+	h := &Handle[T, P]{Value: v, priority: priority, queue: q}
+
+	q.lock.Lock()
+	heap.Push(&q.heap, h)
+	q.lock.Unlock()
+
+	q.signal()
+	return h
+}
+
+// Update changes priority of item in queue
+func (q *PriorityQueue[T, P]) Update(h *Handle[T, P], priority P) error {
+	if q == nil {
+		return ErrNilQueue
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if !q.contains(h) {
+		return ErrInvalidHandle
+	}
+
+	h.priority = priority
+	heap.Fix(&q.heap, h.index)
+	q.signal()
+	return nil
+}
+
+// Remove removes item from queue
+func (q *PriorityQueue[T, P]) Remove(h *Handle[T, P]) error {
+	if q == nil {
+		return ErrNilQueue
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if !q.contains(h) {
+		return ErrInvalidHandle
+	}
+
+	heap.Remove(&q.heap, h.index)
+	return nil
+}
+
+// contains checks if handle belongs to queue, lock must be held
+func (q *PriorityQueue[T, P]) contains(h *Handle[T, P]) bool {
+	return h != nil && h.queue == q && h.index >= 0 && h.index < len(q.heap.list) && q.heap.list[h.index] == h
+}
+
+// Peek returns first item and its priority without removing it
+func (q *PriorityQueue[T, P]) Peek() (v T, priority P, ok bool) {
+	if q == nil {
+		return
+	}
+
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	if len(q.heap.list) == 0 {
+		return
+	}
+	return q.heap.list[0].Value, q.heap.list[0].priority, true
+}
+
+// TryPop removes and returns first item, returns false if queue is empty
+func (q *PriorityQueue[T, P]) TryPop() (v T, ok bool) {
+	h, ok := q.popIf(nil)
+	if !ok {
+		return
+	}
+	return h.Value, true
+}
+
+// popIf removes first item if cond is nil or returns true for it
+func (q *PriorityQueue[T, P]) popIf(cond func(h *Handle[T, P]) bool) (*Handle[T, P], bool) {
+	if q == nil {
+		return nil, false
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.heap.list) == 0 {
+		return nil, false
+	}
+	if cond != nil && !cond(q.heap.list[0]) {
+		return nil, false
+	}
+
+	h := heap.Pop(&q.heap).(*Handle[T, P])
+	if len(q.heap.list) > 0 {
+		// wake next waiting consumer
+		q.signal()
+	}
+	return h, true
+}
+
+// Pop removes and returns first item, waits until an item is available or ctx is done
+func (q *PriorityQueue[T, P]) Pop(ctx context.Context) (T, error) {
+	if q == nil {
+		return *new(T), ErrNilQueue
+	}
+
+	for {
+		if v, ok := q.TryPop(); ok {
+			return v, nil
+		}
+
+		select {
+		case <-q.notify:
+		case <-ctx.Done():
+			return *new(T), ctx.Err()
+		}
+	}
+}
+
+func (q *PriorityQueue[T, P]) Len() int {
+	if q == nil {
+		return 0
+	}
+
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	return len(q.heap.list)
+}
+
+// Clear removes all items, existing handles become invalid
+func (q *PriorityQueue[T, P]) Clear() {
+	if q == nil {
+		return
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for _, h := range q.heap.list {
+		h.index = -1
+	}
+	q.heap.list = nil
+}
@@ -0,0 +1,93 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPriorityQueue(t *testing.T) {
+	q := NewPriorityQueue[string, int]()
+
+	q.Push("c", 3)
+	a := q.Push("a", 5)
+	q.Push("b", 2)
+	d := q.Push("d", 4)
+
+	if err := q.Update(a, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Remove(d); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Remove(d); err != ErrInvalidHandle {
+		t.Errorf("invalid err %v", err)
+	}
+
+	expected := []string{"a", "b", "c"}
+	for _, e := range expected {
+		v, ok := q.TryPop()
+		if !ok || v != e {
+			t.Errorf("%s != %s", v, e)
+		}
+	}
+
+	if q.Len() != 0 {
+		t.Fail()
+	}
+}
+
+func TestPriorityQueuePop(t *testing.T) {
+	q := NewPriorityQueue[string, int]()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Push("x", 1)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	v, err := q.Pop(ctx)
+	if err != nil || v != "x" {
+		t.Errorf("invalid pop %s %v", v, err)
+	}
+}
+
+func TestDelayQueue(t *testing.T) {
+	q := NewDelayQueue[string]()
+
+	q.PutAfter("b", 40*time.Millisecond)
+	q.PutAfter("a", 20*time.Millisecond)
+
+	if _, ok := q.TryTake(); ok {
+		t.Error("item available too early")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	for _, e := range []string{"a", "b"} {
+		v, err := q.Take(ctx)
+		if err != nil || v != e {
+			t.Errorf("invalid take %s %v", v, err)
+		}
+	}
+
+	if time.Since(start) < 40*time.Millisecond {
+		t.Error("items taken too early")
+	}
+}
+
+func TestDelayQueueCancel(t *testing.T) {
+	q := NewDelayQueue[string]()
+	q.PutAfter("x", time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := q.Take(ctx); err != context.DeadlineExceeded {
+		t.Errorf("invalid err %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package rate
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrExceedsBurst = errors.New("requested tokens exceed burst")
+
+// Limiter decides if event may happen now
+type Limiter interface {
+	Allow() bool
+}
+
+// TokenBucket is refilled with rate tokens per second up to burst tokens
+type TokenBucket struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns full bucket
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// advance refills tokens, lock must be held
+func (b *TokenBucket) advance(now time.Time) {
+	if now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+}
+
+// delay returns time needed to refill missing tokens
+func (b *TokenBucket) delay(missing float64) time.Duration {
+	if missing <= 0 {
+		return 0
+	}
+	if b.rate <= 0 {
+		return time.Duration(1<<63 - 1)
+	}
+	return time.Duration(missing / b.rate * float64(time.Second))
+}
+
+func (b *TokenBucket) Allow() bool {
+	return b.AllowN(1)
+}
+
+// AllowN takes n tokens if they are available
+func (b *TokenBucket) AllowN(n int) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.advance(time.Now())
+	if b.tokens < float64(n) {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
+
+// Tokens returns number of available tokens
+func (b *TokenBucket) Tokens() float64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.advance(time.Now())
+	return b.tokens
+}
+
+// Reservation is a permission to act after Delay
+type Reservation struct {
+	bucket *TokenBucket
+	n      float64
+	at     time.Time
+	ok     bool
+}
+
+// OK returns false if reservation could never be satisfied
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay returns time to wait before acting
+func (r *Reservation) Delay() time.Duration {
+	if d := time.Until(r.at); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Cancel returns tokens of reservation which was not used
+func (r *Reservation) Cancel() {
+	if !r.ok {
+		return
+	}
+	r.ok = false
+
+	b := r.bucket
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.advance(time.Now())
+	b.tokens += r.n
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+func (b *TokenBucket) Reserve() *Reservation {
+	return b.ReserveN(1)
+}
+
+// ReserveN takes n tokens in advance, caller has to wait Delay before acting
+func (b *TokenBucket) ReserveN(n int) *Reservation {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if float64(n) > b.burst {
+		return &Reservation{bucket: b}
+	}
+
+	now := time.Now()
+	b.advance(now)
+	b.tokens -= float64(n)
+	return &Reservation{
+		bucket: b,
+		n:      float64(n),
+		at:     now.Add(b.delay(-b.tokens)),
+		ok:     true,
+	}
+}
+
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return b.WaitN(ctx, 1)
+}
+
+// WaitN waits until n tokens are available, tokens are returned if ctx is done first
+func (b *TokenBucket) WaitN(ctx context.Context, n int) error {
+	r := b.ReserveN(n)
+	if !r.OK() {
+		return ErrExceedsBurst
+	}
+
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(r.at) {
+		r.Cancel()
+		return context.DeadlineExceeded
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,106 @@
+package rate
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/timoni-io/go-utils"
+	"github.com/timoni-io/go-utils/maps"
+)
+
+// minEvictInterval limits how often idle keys are checked
+const minEvictInterval = time.Millisecond
+
+// evicted is stored in keyedEntry.used when entry is removed by evict
+const evicted = -1
+
+type keyedEntry struct {
+	limiter Limiter
+	// unix nanoseconds of the last use
+	used atomic.Int64
+}
+
+// Keyed keeps separate limiter for every key, limiters idle longer than idle are evicted
+type Keyed[K comparable] struct {
+	limiters *maps.Map[K, *keyedEntry]
+	factory  func() Limiter
+	idle     time.Duration
+}
+
+// NewKeyed creates limiter which evicts idle keys until ctx is done
+func NewKeyed[K comparable](ctx context.Context, idle time.Duration, factory func() Limiter) *Keyed[K] {
+	k := &Keyed[K]{
+		limiters: maps.New(map[K]*keyedEntry{}).Safe(),
+		factory:  factory,
+		idle:     idle,
+	}
+
+	if idle > 0 {
+		go k.evict(ctx)
+	}
+	return k
+}
+
+// Allow checks limiter of key, limiter is created on first use
+func (k *Keyed[K]) Allow(key K) bool {
+	// entry evicted after lookup is put back, so its limiter state is kept
+	e, ok := k.limiters.GetFull(key)
+	if !ok || e.used.Swap(time.Now().UnixNano()) == evicted {
+		k.limiters.Commit(func(data map[K]*keyedEntry) {
+			if current, exists := data[key]; exists {
+				e = current
+			} else if e == nil {
+				e = &keyedEntry{limiter: k.factory()}
+			}
+			e.used.Store(time.Now().UnixNano())
+			data[key] = e
+		})
+	}
+
+	return e.limiter.Allow()
+}
+
+// Len returns number of tracked keys
+func (k *Keyed[K]) Len() int {
+	return k.limiters.Len()
+}
+
+func (k *Keyed[K]) evict(ctx context.Context) {
+	interval := k.idle / 2
+	if interval < minEvictInterval {
+		interval = minEvictInterval
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		deadline := time.Now().Add(-k.idle).UnixNano()
+		k.limiters.Commit(func(data map[K]*keyedEntry) {
+			for key, e := range data {
+				// CompareAndSwap fails if Allow used the entry after Load
+				if used := e.used.Load(); used < deadline && e.used.CompareAndSwap(used, evicted) {
+					delete(data, key)
+				}
+			}
+		})
+	}
+}
+
+// Middleware rejects requests over limit of client IP with 429 Too Many Requests
+func Middleware(limiter *Keyed[string], next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !limiter.Allow(utils.RequestIP(r)) {
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
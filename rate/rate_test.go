@@ -0,0 +1,157 @@
+package rate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := NewTokenBucket(100, 2)
+
+	if !b.Allow() || !b.Allow() || b.Allow() {
+		t.Error("burst not respected")
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	if !b.Allow() {
+		t.Error("bucket not refilled")
+	}
+	if b.AllowN(3) {
+		t.Error("allowed more than burst")
+	}
+}
+
+func TestTokenBucketReserve(t *testing.T) {
+	b := NewTokenBucket(100, 1)
+	b.Allow()
+
+	r := b.Reserve()
+	if !r.OK() || r.Delay() < 5*time.Millisecond {
+		t.Errorf("unexpected delay %v", r.Delay())
+	}
+	r.Cancel()
+	if tokens := b.Tokens(); tokens < -0.1 {
+		t.Errorf("tokens not returned, %v", tokens)
+	}
+
+	if b.ReserveN(2).OK() {
+		t.Error("reserved more than burst")
+	}
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	b := NewTokenBucket(100, 1)
+	b.Allow()
+
+	start := time.Now()
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 5*time.Millisecond {
+		t.Error("wait returned too early")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if err := b.WaitN(context.Background(), 2); err != ErrExceedsBurst {
+		t.Errorf("expected ErrExceedsBurst, got %v", err)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	w := NewSlidingWindow(3, 20*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		if !w.Allow() {
+			t.Fatalf("event %d not allowed", i)
+		}
+	}
+	if w.Allow() {
+		t.Error("limit not respected")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if !w.Allow() || w.Count() > 1.01 {
+		t.Errorf("window not moved, count %v", w.Count())
+	}
+}
+
+func TestZeroWindow(t *testing.T) {
+	w := NewSlidingWindow(1, 0)
+	w.Allow()
+	w.Allow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	k := NewKeyed[string](ctx, time.Nanosecond, func() Limiter { return w })
+	k.Allow("a")
+	time.Sleep(5 * time.Millisecond)
+}
+
+func TestKeyed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	k := NewKeyed[string](ctx, 20*time.Millisecond, func() Limiter { return NewTokenBucket(0, 1) })
+	if !k.Allow("a") || k.Allow("a") || !k.Allow("b") {
+		t.Error("keys not limited separately")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if k.Len() != 0 {
+		t.Errorf("idle keys not evicted, %d left", k.Len())
+	}
+}
+
+func TestKeyedEvictInUse(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	k := NewKeyed[string](ctx, 10*time.Millisecond, func() Limiter { return NewTokenBucket(0, 1) })
+
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	deadline := time.Now().Add(100 * time.Millisecond)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				if k.Allow("a") {
+					allowed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := allowed.Load(); n != 1 {
+		t.Errorf("limiter of used key was recreated, %d requests allowed", n)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	k := NewKeyed[string](context.Background(), 0, func() Limiter { return NewTokenBucket(0, 1) })
+	h := Middleware(k, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := []int{}
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		w := httptest.NewRecorder()
+		h(w, r)
+		codes = append(codes, w.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("unexpected codes %v", codes)
+	}
+}
@@ -0,0 +1,74 @@
+package rate
+
+import (
+	"sync"
+	"time"
+)
+
+// SlidingWindow allows limit events per window. Count is estimated from the current
+// and the previous fixed window weighted by their overlap with the sliding one.
+type SlidingWindow struct {
+	lock   sync.Mutex
+	limit  int
+	window time.Duration
+	start  time.Time
+	curr   int
+	prev   int
+}
+
+// NewSlidingWindow creates limiter, window <= 0 is treated as 1ns
+func NewSlidingWindow(limit int, window time.Duration) *SlidingWindow {
+	if window <= 0 {
+		window = time.Nanosecond
+	}
+	return &SlidingWindow{
+		limit:  limit,
+		window: window,
+		start:  time.Now(),
+	}
+}
+
+// advance moves fixed windows, lock must be held
+func (w *SlidingWindow) advance(now time.Time) {
+	elapsed := now.Sub(w.start)
+	if elapsed < w.window {
+		return
+	}
+
+	if elapsed < 2*w.window {
+		w.prev = w.curr
+	} else {
+		w.prev = 0
+	}
+	w.curr = 0
+	w.start = w.start.Add(elapsed / w.window * w.window)
+}
+
+// count returns estimated number of events in sliding window, lock must be held
+func (w *SlidingWindow) count(now time.Time) float64 {
+	overlap := 1 - float64(now.Sub(w.start))/float64(w.window)
+	return float64(w.prev)*overlap + float64(w.curr)
+}
+
+func (w *SlidingWindow) Allow() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	now := time.Now()
+	w.advance(now)
+	if w.count(now)+1 > float64(w.limit) {
+		return false
+	}
+	w.curr++
+	return true
+}
+
+// Count returns estimated number of events in the last window
+func (w *SlidingWindow) Count() float64 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	now := time.Now()
+	w.advance(now)
+	return w.count(now)
+}
@@ -0,0 +1,337 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrPoolClosed = errors.New("pool is closed")
+
+type ResourceOptions[T any] struct {
+	// creates new object, required
+	Factory func(ctx context.Context) (T, error)
+	// releases object when it is discarded or evicted, optional
+	Close func(v T)
+	// checks idle object before it is borrowed, broken objects are discarded
+	Check func(v T) error
+
+	// max number of objects, borrowed and idle, unlimited if <= 0
+	MaxSize int
+	// number of idle objects kept ready
+	MinIdle int
+	// objects older than MaxLifetime are evicted, no limit if <= 0
+	MaxLifetime time.Duration
+	// objects idle longer than IdleTimeout are evicted, no limit if <= 0
+	IdleTimeout time.Duration
+}
+
+type ResourceStats struct {
+	Size      int
+	Idle      int
+	InUse     int
+	Created   int64
+	Discarded int64
+	// number of Get calls which had to wait for free object
+	Waited int64
+}
+
+// Resource is an object borrowed from ResourcePool
+type Resource[T any] struct {
+	Value T
+
+	created  time.Time
+	returned time.Time
+	borrowed bool
+}
+
+// ResourcePool lends objects created lazily by factory
+type ResourcePool[T any] struct {
+	opts   ResourceOptions[T]
+	lock   sync.Mutex
+	idle   []*Resource[T]
+	size   int
+	closed bool
+	// closed and replaced when object is returned or slot is freed
+	notify chan struct{}
+	stats  ResourceStats
+}
+
+// NewResourcePool creates pool, it evicts expired objects in background and is closed when ctx is done
+func NewResourcePool[T any](ctx context.Context, opts ResourceOptions[T]) *ResourcePool[T] {
+	p := &ResourcePool[T]{
+		opts:   opts,
+		notify: make(chan struct{}),
+	}
+	go p.maintain(ctx)
+	return p
+}
+
+// signal wakes up waiting Get calls, lock must be held
+func (p *ResourcePool[T]) signal() {
+	close(p.notify)
+	p.notify = make(chan struct{})
+}
+
+// expired checks lifetime and idle timeout
+func (p *ResourcePool[T]) expired(r *Resource[T], now time.Time) bool {
+	return (p.opts.MaxLifetime > 0 && now.Sub(r.created) > p.opts.MaxLifetime) ||
+		(p.opts.IdleTimeout > 0 && now.Sub(r.returned) > p.opts.IdleTimeout)
+}
+
+func (p *ResourcePool[T]) close(rs []*Resource[T]) {
+	if p.opts.Close == nil {
+		return
+	}
+	for _, r := range rs {
+		p.opts.Close(r.Value)
+	}
+}
+
+// Get borrows idle object or creates new one, waits while pool is full until ctx is done
+func (p *ResourcePool[T]) Get(ctx context.Context) (*Resource[T], error) {
+	waited := false
+	for {
+		p.lock.Lock()
+		if p.closed {
+			p.lock.Unlock()
+			return nil, ErrPoolClosed
+		}
+
+		r, expired := p.popIdle()
+		if r != nil || len(expired) > 0 {
+			p.lock.Unlock()
+			p.close(expired)
+
+			if r == nil {
+				continue
+			}
+			if p.opts.Check != nil && p.opts.Check(r.Value) != nil {
+				p.Discard(r)
+				continue
+			}
+			return r, nil
+		}
+
+		if p.opts.MaxSize <= 0 || p.size < p.opts.MaxSize {
+			p.size++
+			p.lock.Unlock()
+			return p.create(ctx)
+		}
+
+		if !waited {
+			waited = true
+			p.stats.Waited++
+		}
+		notify := p.notify
+		p.lock.Unlock()
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// popIdle returns the most recently used valid idle object and removed expired ones, lock must be held
+func (p *ResourcePool[T]) popIdle() (*Resource[T], []*Resource[T]) {
+	now := time.Now()
+	var expired []*Resource[T]
+
+	for len(p.idle) > 0 {
+		r := p.idle[len(p.idle)-1]
+		p.idle[len(p.idle)-1] = nil
+		p.idle = p.idle[:len(p.idle)-1]
+
+		if p.expired(r, now) {
+			p.size--
+			p.signal()
+			p.stats.Discarded++
+			expired = append(expired, r)
+			continue
+		}
+
+		r.borrowed = true
+		return r, expired
+	}
+	return nil, expired
+}
+
+// create creates object in slot reserved by caller
+func (p *ResourcePool[T]) create(ctx context.Context) (*Resource[T], error) {
+	v, err := p.opts.Factory(ctx)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if err != nil {
+		p.size--
+		p.signal()
+		return nil, err
+	}
+
+	p.stats.Created++
+	now := time.Now()
+	return &Resource[T]{Value: v, created: now, returned: now, borrowed: true}, nil
+}
+
+// Put returns borrowed object to pool
+func (p *ResourcePool[T]) Put(r *Resource[T]) {
+	if r == nil {
+		return
+	}
+
+	p.lock.Lock()
+	if !r.borrowed {
+		p.lock.Unlock()
+		return
+	}
+	r.borrowed = false
+	r.returned = time.Now()
+
+	if p.closed || p.expired(r, r.returned) {
+		p.size--
+		p.stats.Discarded++
+		p.signal()
+		p.lock.Unlock()
+		p.close([]*Resource[T]{r})
+		return
+	}
+
+	p.idle = append(p.idle, r)
+	p.signal()
+	p.lock.Unlock()
+}
+
+// Discard removes broken borrowed object from pool
+func (p *ResourcePool[T]) Discard(r *Resource[T]) {
+	if r == nil {
+		return
+	}
+
+	p.lock.Lock()
+	if !r.borrowed {
+		p.lock.Unlock()
+		return
+	}
+	r.borrowed = false
+	p.size--
+	p.stats.Discarded++
+	p.signal()
+	p.lock.Unlock()
+
+	p.close([]*Resource[T]{r})
+}
+
+// Stats returns current pool stats
+func (p *ResourcePool[T]) Stats() ResourceStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	stats := p.stats
+	stats.Size = p.size
+	stats.Idle = len(p.idle)
+	stats.InUse = p.size - len(p.idle)
+	return stats
+}
+
+// Close closes idle objects, borrowed ones are closed when returned
+func (p *ResourcePool[T]) Close() {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.size -= len(idle)
+	p.stats.Discarded += int64(len(idle))
+	p.signal()
+	p.lock.Unlock()
+
+	p.close(idle)
+}
+
+// maintain evicts expired objects and keeps MinIdle objects ready
+func (p *ResourcePool[T]) maintain(ctx context.Context) {
+	interval := time.Second
+	for _, d := range []time.Duration{p.opts.IdleTimeout / 2, p.opts.MaxLifetime / 2} {
+		if d > 0 && d < interval {
+			interval = d
+		}
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	p.fill(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.Close()
+			return
+		case <-t.C:
+			if !p.evict() {
+				return
+			}
+			p.fill(ctx)
+		}
+	}
+}
+
+// evict closes expired idle objects, returns false if pool is closed
+func (p *ResourcePool[T]) evict() bool {
+	now := time.Now()
+
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return false
+	}
+
+	var expired []*Resource[T]
+	idle := p.idle[:0]
+	for _, r := range p.idle {
+		if p.expired(r, now) {
+			expired = append(expired, r)
+		} else {
+			idle = append(idle, r)
+		}
+	}
+	for i := len(idle); i < len(p.idle); i++ {
+		p.idle[i] = nil
+	}
+	p.idle = idle
+	p.size -= len(expired)
+	p.stats.Discarded += int64(len(expired))
+	if len(expired) > 0 {
+		p.signal()
+	}
+	p.lock.Unlock()
+
+	p.close(expired)
+	return true
+}
+
+// fill creates objects until there are MinIdle idle ones
+func (p *ResourcePool[T]) fill(ctx context.Context) {
+	for {
+		p.lock.Lock()
+		if p.closed || len(p.idle) >= p.opts.MinIdle || (p.opts.MaxSize > 0 && p.size >= p.opts.MaxSize) {
+			p.lock.Unlock()
+			return
+		}
+		p.size++
+		p.lock.Unlock()
+
+		r, err := p.create(ctx)
+		if err != nil {
+			return
+		}
+		p.Put(r)
+	}
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type conn struct {
+	id     int32
+	broken bool
+}
+
+func newConnPool(ctx context.Context, opts ResourceOptions[*conn]) (*ResourcePool[*conn], *int32) {
+	var closed int32
+	var ids int32
+	opts.Factory = func(context.Context) (*conn, error) {
+		return &conn{id: atomic.AddInt32(&ids, 1)}, nil
+	}
+	opts.Close = func(*conn) { atomic.AddInt32(&closed, 1) }
+	return NewResourcePool(ctx, opts), &closed
+}
+
+func TestResourcePoolReuse(t *testing.T) {
+	ctx := context.Background()
+	p, _ := newConnPool(ctx, ResourceOptions[*conn]{MaxSize: 2})
+	defer p.Close()
+
+	a, _ := p.Get(ctx)
+	p.Put(a)
+	b, _ := p.Get(ctx)
+	if a.Value != b.Value {
+		t.Error("idle object not reused")
+	}
+
+	stats := p.Stats()
+	if stats.Created != 1 || stats.InUse != 1 || stats.Idle != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestResourcePoolMaxSize(t *testing.T) {
+	ctx := context.Background()
+	p, _ := newConnPool(ctx, ResourceOptions[*conn]{MaxSize: 1})
+	defer p.Close()
+
+	a, _ := p.Get(ctx)
+
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(tctx); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		p.Put(a)
+	}()
+	if b, err := p.Get(ctx); err != nil || b.Value != a.Value {
+		t.Errorf("unexpected result %v", err)
+	}
+	if p.Stats().Waited != 2 {
+		t.Errorf("unexpected stats %+v", p.Stats())
+	}
+}
+
+func TestResourcePoolCheck(t *testing.T) {
+	ctx := context.Background()
+	p, closed := newConnPool(ctx, ResourceOptions[*conn]{
+		Check: func(c *conn) error {
+			if c.broken {
+				return errors.New("broken")
+			}
+			return nil
+		},
+	})
+	defer p.Close()
+
+	a, _ := p.Get(ctx)
+	a.Value.broken = true
+	p.Put(a)
+
+	b, _ := p.Get(ctx)
+	if b.Value == a.Value || atomic.LoadInt32(closed) != 1 {
+		t.Error("broken object not discarded")
+	}
+
+	p.Discard(b)
+	if atomic.LoadInt32(closed) != 2 || p.Stats().Size != 0 {
+		t.Errorf("unexpected stats %+v", p.Stats())
+	}
+}
+
+func TestResourcePoolIdleTimeout(t *testing.T) {
+	ctx := context.Background()
+	p, closed := newConnPool(ctx, ResourceOptions[*conn]{IdleTimeout: 10 * time.Millisecond, MinIdle: 1})
+	defer p.Close()
+
+	a, _ := p.Get(ctx)
+	p.Put(a)
+
+	time.Sleep(50 * time.Millisecond)
+	if atomic.LoadInt32(closed) == 0 {
+		t.Error("idle object not evicted")
+	}
+	if p.Stats().Idle != 1 {
+		t.Errorf("min idle not kept, %+v", p.Stats())
+	}
+}
+
+func TestResourcePoolClose(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p, closed := newConnPool(ctx, ResourceOptions[*conn]{})
+
+	a, _ := p.Get(ctx)
+	b, _ := p.Get(ctx)
+	p.Put(a)
+
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+
+	if _, err := p.Get(context.Background()); err != ErrPoolClosed {
+		t.Errorf("expected ErrPoolClosed, got %v", err)
+	}
+	p.Put(b)
+	if atomic.LoadInt32(closed) != 2 {
+		t.Errorf("objects not closed, %d", atomic.LoadInt32(closed))
+	}
+}
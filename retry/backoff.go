@@ -0,0 +1,48 @@
+package retry
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff returns delay before retry, attempt starts from 1, prev is the previous delay
+type Backoff func(attempt int, prev time.Duration) time.Duration
+
+// Constant waits d before every retry
+func Constant(d time.Duration) Backoff {
+	return func(int, time.Duration) time.Duration {
+		return d
+	}
+}
+
+// Exponential doubles delay from base up to max
+func Exponential(base, max time.Duration) Backoff {
+	return func(attempt int, _ time.Duration) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+// DecorrelatedJitter returns random delay between base and three times the previous delay, up to max
+func DecorrelatedJitter(base, max time.Duration) Backoff {
+	return func(_ int, prev time.Duration) time.Duration {
+		if prev < base {
+			prev = base
+		}
+
+		upper := prev * 3
+		if upper > max || upper < 0 {
+			upper = max
+		}
+		if upper <= base {
+			return base
+		}
+		return base + time.Duration(rand.Int63n(int64(upper-base)))
+	}
+}
@@ -0,0 +1,281 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/timoni-io/go-utils"
+)
+
+var (
+	ErrOpen            = errors.New("circuit breaker is open")
+	ErrTooManyRequests = errors.New("circuit breaker is half-open, too many requests")
+)
+
+type State uint8
+
+const (
+	// requests pass, failures are counted
+	Closed State = iota
+	// requests are rejected until OpenTimeout passes
+	Open
+	// limited number of probe requests decide if breaker closes or opens again
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+type BreakerOptions struct {
+	// failure rate is computed over the last Window, 10s if <= 0, at least 10ns
+	Window time.Duration
+	// breaker doesn't open before MinRequests in window, 10 if <= 0
+	MinRequests int
+	// failure rate opening the breaker, 0.5 if <= 0
+	FailureRate float64
+	// time in open state before probing, 5s if <= 0
+	OpenTimeout time.Duration
+	// number of probes in half-open state, all must succeed to close, 1 if <= 0
+	HalfOpenRequests int
+	// breaker opens again if probes don't finish within ProbeTimeout, OpenTimeout if <= 0
+	ProbeTimeout time.Duration
+	// decides if error counts as failure, every error does if nil
+	IsFailure func(err error) bool
+	// called after state change
+	OnStateChange func(from, to State)
+}
+
+// windowBuckets is number of buckets the window is split into
+const windowBuckets = 10
+
+type bucket struct {
+	start    time.Time
+	requests int
+	failures int
+}
+
+// Breaker stops calling failing dependency for a while
+type Breaker struct {
+	opts BreakerOptions
+
+	lock  sync.Mutex
+	state State
+	// time of the last state change
+	since time.Time
+	// incremented on state change, results of requests started before are ignored
+	gen     uint64
+	buckets [windowBuckets]bucket
+	// probes running and succeeded in half-open state
+	probes    int
+	succeeded int
+}
+
+func NewBreaker(opts BreakerOptions) *Breaker {
+	if opts.Window <= 0 {
+		opts.Window = 10 * time.Second
+	} else if opts.Window < windowBuckets {
+		// bucket size can't be 0
+		opts.Window = windowBuckets
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = 10
+	}
+	if opts.FailureRate <= 0 {
+		opts.FailureRate = 0.5
+	}
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = 5 * time.Second
+	}
+	if opts.HalfOpenRequests <= 0 {
+		opts.HalfOpenRequests = 1
+	}
+	if opts.ProbeTimeout <= 0 {
+		opts.ProbeTimeout = opts.OpenTimeout
+	}
+	return &Breaker{opts: opts}
+}
+
+// State returns current state
+func (b *Breaker) State() State {
+	b.lock.Lock()
+	from, to := b.refresh(time.Now())
+	state := b.state
+	b.lock.Unlock()
+
+	b.changed(from, to)
+	return state
+}
+
+// Counts returns number of requests and failures in window
+func (b *Breaker) Counts() (requests, failures int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := time.Now()
+	for _, bk := range b.buckets {
+		if now.Sub(bk.start) < b.opts.Window {
+			requests += bk.requests
+			failures += bk.failures
+		}
+	}
+	return
+}
+
+// refresh moves open breaker to half-open after timeout
+// and half-open one back to open when probes time out, lock must be held
+func (b *Breaker) refresh(now time.Time) (State, State) {
+	switch {
+	case b.state == Open && now.Sub(b.since) >= b.opts.OpenTimeout:
+		return b.set(HalfOpen, now)
+	case b.state == HalfOpen && b.probes > b.succeeded && now.Sub(b.since) >= b.opts.ProbeTimeout:
+		return b.set(Open, now)
+	}
+	return b.state, b.state
+}
+
+// set changes state, lock must be held
+func (b *Breaker) set(state State, now time.Time) (State, State) {
+	from := b.state
+	b.state = state
+	b.since = now
+	b.gen++
+	b.probes, b.succeeded = 0, 0
+
+	if state == Closed {
+		b.buckets = [windowBuckets]bucket{}
+	}
+	return from, state
+}
+
+func (b *Breaker) changed(from, to State) {
+	if from != to && b.opts.OnStateChange != nil {
+		b.opts.OnStateChange(from, to)
+	}
+}
+
+// current returns bucket for now, lock must be held
+func (b *Breaker) current(now time.Time) *bucket {
+	size := b.opts.Window / windowBuckets
+	start := now.Truncate(size)
+	bk := &b.buckets[start.UnixNano()/int64(size)%windowBuckets]
+	if !bk.start.Equal(start) {
+		*bk = bucket{start: start}
+	}
+	return bk
+}
+
+// Allow checks if request may pass, done must be called with request result
+func (b *Breaker) Allow() (done func(err error), err error) {
+	b.lock.Lock()
+	now := time.Now()
+	from, to := b.refresh(now)
+
+	switch b.state {
+	case Open:
+		err = ErrOpen
+	case HalfOpen:
+		if b.probes >= b.opts.HalfOpenRequests {
+			err = ErrTooManyRequests
+		} else {
+			b.probes++
+		}
+	}
+	gen := b.gen
+	b.lock.Unlock()
+
+	b.changed(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() { b.done(gen, err) })
+	}, nil
+}
+
+func (b *Breaker) done(gen uint64, err error) {
+	failed := err != nil
+	if failed && b.opts.IsFailure != nil {
+		failed = b.opts.IsFailure(err)
+	}
+
+	b.lock.Lock()
+	now := time.Now()
+	from, to := b.state, b.state
+
+	// ignore results of requests started before state change
+	if gen == b.gen {
+		switch b.state {
+		case Closed:
+			bk := b.current(now)
+			bk.requests++
+			if failed {
+				bk.failures++
+			}
+			if failed && b.tripped(now) {
+				from, to = b.set(Open, now)
+			}
+
+		case HalfOpen:
+			if failed {
+				from, to = b.set(Open, now)
+			} else if b.succeeded++; b.succeeded >= b.opts.HalfOpenRequests {
+				from, to = b.set(Closed, now)
+			}
+		}
+	}
+	b.lock.Unlock()
+
+	b.changed(from, to)
+}
+
+// tripped checks failure rate in window, lock must be held
+func (b *Breaker) tripped(now time.Time) bool {
+	requests, failures := 0, 0
+	for _, bk := range b.buckets {
+		if now.Sub(bk.start) < b.opts.Window {
+			requests += bk.requests
+			failures += bk.failures
+		}
+	}
+	return requests >= b.opts.MinRequests && float64(failures)/float64(requests) >= b.opts.FailureRate
+}
+
+// Run calls fn if breaker allows it
+func (b *Breaker) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	_, err := Call(ctx, b, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// Call calls fn if breaker allows it, returns ErrOpen or ErrTooManyRequests otherwise
+func Call[T any](ctx context.Context, b *Breaker, fn func(ctx context.Context) (T, error)) (v T, err error) {
+	done, err := b.Allow()
+	if err != nil {
+		return v, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			// panic is reported to panic hook by whoever recovers it finally
+			done(&utils.PanicError{Value: r, Stack: debug.Stack()})
+			panic(r)
+		}
+		done(err)
+	}()
+	return fn(ctx)
+}
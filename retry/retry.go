@@ -0,0 +1,93 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type Options struct {
+	// max number of attempts including the first one, 3 if <= 0
+	Attempts int
+	// no retry is started after MaxElapsed since the first attempt, no limit if <= 0
+	MaxElapsed time.Duration
+	// Exponential(100ms, 10s) if nil
+	Backoff Backoff
+	// decides if error is worth retrying, all errors are retried if nil
+	RetryIf func(err error) bool
+	// called before waiting for retry
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+type permanent struct {
+	err error
+}
+
+func (p permanent) Error() string {
+	return p.err.Error()
+}
+
+func (p permanent) Unwrap() error {
+	return p.err
+}
+
+// Permanent wraps error which stops retrying, Do returns the wrapped error
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanent{err: err}
+}
+
+// Do calls fn until it succeeds, attempts are exhausted or ctx is done.
+// Returns the last fn error, or ctx error if ctx is done while waiting.
+func Do[T any](ctx context.Context, opts Options, fn func(ctx context.Context) (T, error)) (T, error) {
+	if opts.Attempts <= 0 {
+		opts.Attempts = 3
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = Exponential(100*time.Millisecond, 10*time.Second)
+	}
+
+	start := time.Now()
+	var delay time.Duration
+
+	for attempt := 1; ; attempt++ {
+		v, err := fn(ctx)
+		if err == nil {
+			return v, nil
+		}
+
+		var p permanent
+		if errors.As(err, &p) {
+			return v, p.err
+		}
+		if attempt >= opts.Attempts || ctx.Err() != nil || (opts.RetryIf != nil && !opts.RetryIf(err)) {
+			return v, err
+		}
+
+		delay = opts.Backoff(attempt, delay)
+		if opts.MaxElapsed > 0 && time.Since(start)+delay > opts.MaxElapsed {
+			return v, err
+		}
+		if opts.OnRetry != nil {
+			opts.OnRetry(attempt, err, delay)
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return v, ctx.Err()
+		}
+	}
+}
+
+// Run is Do for functions without result
+func Run(ctx context.Context, opts Options, fn func(ctx context.Context) error) error {
+	_, err := Do(ctx, opts, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
@@ -0,0 +1,232 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/timoni-io/go-utils"
+)
+
+var errTest = errors.New("test")
+
+func TestDo(t *testing.T) {
+	calls := 0
+	v, err := Do(context.Background(), Options{Attempts: 3, Backoff: Constant(time.Millisecond)}, func(ctx context.Context) (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, errTest
+		}
+		return calls, nil
+	})
+	if err != nil || v != 3 {
+		t.Errorf("unexpected result %d, %v", v, err)
+	}
+
+	calls = 0
+	_, err = Do(context.Background(), Options{Attempts: 2, Backoff: Constant(time.Millisecond)}, func(ctx context.Context) (int, error) {
+		calls++
+		return 0, errTest
+	})
+	if err != errTest || calls != 2 {
+		t.Errorf("attempts not respected, %d calls, %v", calls, err)
+	}
+}
+
+func TestDoStop(t *testing.T) {
+	calls := 0
+	err := Run(context.Background(), Options{Attempts: 5, Backoff: Constant(time.Millisecond)}, func(ctx context.Context) error {
+		calls++
+		return Permanent(errTest)
+	})
+	if err != errTest || calls != 1 {
+		t.Errorf("permanent error retried, %d calls, %v", calls, err)
+	}
+
+	calls = 0
+	err = Run(context.Background(), Options{
+		Attempts: 5,
+		Backoff:  Constant(time.Millisecond),
+		RetryIf:  func(err error) bool { return err != errTest },
+	}, func(ctx context.Context) error {
+		calls++
+		return errTest
+	})
+	if err != errTest || calls != 1 {
+		t.Errorf("RetryIf not respected, %d calls", calls)
+	}
+
+	calls = 0
+	err = Run(context.Background(), Options{Attempts: 100, MaxElapsed: 20 * time.Millisecond, Backoff: Constant(10 * time.Millisecond)}, func(ctx context.Context) error {
+		calls++
+		return errTest
+	})
+	if err != errTest || calls > 3 {
+		t.Errorf("MaxElapsed not respected, %d calls", calls)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = Run(ctx, Options{Attempts: 5, Backoff: Constant(time.Second)}, func(ctx context.Context) error {
+		return errTest
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	e := Exponential(10*time.Millisecond, 50*time.Millisecond)
+	if e(1, 0) != 10*time.Millisecond || e(3, 0) != 40*time.Millisecond || e(10, 0) != 50*time.Millisecond {
+		t.Error("unexpected exponential delays")
+	}
+
+	j := DecorrelatedJitter(10*time.Millisecond, 50*time.Millisecond)
+	var prev time.Duration
+	for i := 1; i < 20; i++ {
+		prev = j(i, prev)
+		if prev < 10*time.Millisecond || prev > 50*time.Millisecond {
+			t.Fatalf("jitter delay out of range, %v", prev)
+		}
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	changes := []State{}
+	b := NewBreaker(BreakerOptions{
+		MinRequests:   4,
+		FailureRate:   0.5,
+		OpenTimeout:   20 * time.Millisecond,
+		OnStateChange: func(from, to State) { changes = append(changes, to) },
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		b.Run(ctx, func(ctx context.Context) error {
+			if i%2 == 1 {
+				return errTest
+			}
+			return nil
+		})
+	}
+	if b.State() != Open {
+		t.Fatalf("breaker not opened, %v", b.State())
+	}
+
+	_, err := Call(ctx, b, func(ctx context.Context) (int, error) { return 1, nil })
+	if err != ErrOpen {
+		t.Errorf("expected ErrOpen, got %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	done, err := b.Allow()
+	if err != nil || b.State() != HalfOpen {
+		t.Fatalf("breaker not half-open, %v", err)
+	}
+	if _, err := b.Allow(); err != ErrTooManyRequests {
+		t.Errorf("expected ErrTooManyRequests, got %v", err)
+	}
+
+	done(nil)
+	if b.State() != Closed {
+		t.Errorf("breaker not closed, %v", b.State())
+	}
+	if len(changes) != 3 || changes[0] != Open || changes[1] != HalfOpen || changes[2] != Closed {
+		t.Errorf("unexpected state changes %v", changes)
+	}
+}
+
+func TestBreakerIsFailure(t *testing.T) {
+	b := NewBreaker(BreakerOptions{
+		MinRequests: 1,
+		IsFailure:   func(err error) bool { return err != errTest },
+	})
+
+	b.Run(context.Background(), func(ctx context.Context) error { return errTest })
+	if b.State() != Closed {
+		t.Error("ignored error opened breaker")
+	}
+	if requests, failures := b.Counts(); requests != 1 || failures != 0 {
+		t.Errorf("unexpected counts %d, %d", requests, failures)
+	}
+}
+
+func TestBreakerPanic(t *testing.T) {
+	var hooked int32
+	utils.SetPanicHook(func(err *utils.PanicError) { atomic.AddInt32(&hooked, 1) })
+	defer utils.SetPanicHook(nil)
+
+	var failure error
+	b := NewBreaker(BreakerOptions{
+		MinRequests: 1,
+		IsFailure: func(err error) bool {
+			failure = err
+			return err != nil
+		},
+	})
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic was not propagated")
+			}
+		}()
+		b.Run(context.Background(), func(ctx context.Context) error { panic("boom") })
+	}()
+
+	var pe *utils.PanicError
+	if !errors.As(failure, &pe) || pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Errorf("unexpected failure %v", failure)
+	}
+	if _, failures := b.Counts(); failures != 1 {
+		t.Errorf("unexpected failures %d", failures)
+	}
+	// panic is reported only by the final recover
+	if n := atomic.LoadInt32(&hooked); n != 0 {
+		t.Errorf("panic hook called %d times", n)
+	}
+}
+
+func TestBreakerProbeTimeout(t *testing.T) {
+	b := NewBreaker(BreakerOptions{
+		MinRequests:  1,
+		OpenTimeout:  10 * time.Millisecond,
+		ProbeTimeout: 10 * time.Millisecond,
+	})
+
+	b.Run(context.Background(), func(ctx context.Context) error { return errTest })
+	time.Sleep(15 * time.Millisecond)
+
+	// probe which never finishes
+	stale, err := b.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(15 * time.Millisecond)
+	if b.State() != Open {
+		t.Fatalf("breaker stuck %v", b.State())
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale(nil)
+	if b.State() != HalfOpen {
+		t.Errorf("result of timed out probe was counted, %v", b.State())
+	}
+	done(nil)
+	if b.State() != Closed {
+		t.Errorf("breaker not closed, %v", b.State())
+	}
+}
+
+func TestBreakerSmallWindow(t *testing.T) {
+	b := NewBreaker(BreakerOptions{Window: 5})
+	if err := b.Run(context.Background(), func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+}
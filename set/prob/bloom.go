@@ -0,0 +1,243 @@
+package prob
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/timoni-io/go-utils"
+	"github.com/timoni-io/go-utils/types"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// --- Bloom filter ---
+
+type Bloom struct {
+	lock *utils.Lock
+	bits []uint64
+	m    uint64 // bits count
+	k    uint64 // hash functions count
+	n    uint64 // added items count
+}
+
+// NewBloom creates filter sized for n items with given false positive rate
+func NewBloom(n uint64, fpRate float64) *Bloom {
+	m, k := optimal(n, fpRate)
+	return &Bloom{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+func NewSafeBloom(n uint64, fpRate float64) *Bloom {
+	b := NewBloom(n, fpRate)
+	b.lock = &utils.Lock{}
+	return b
+}
+
+func (b *Bloom) add(data []byte) (exists bool) {
+	exists = true
+	h1, h2 := hashes(data)
+	for i := uint64(0); i < b.k; i++ {
+		loc := location(h1, h2, i, b.m)
+		if b.bits[loc/64]&(1<<(loc%64)) == 0 {
+			exists = false
+			b.bits[loc/64] |= 1 << (loc % 64)
+		}
+	}
+	if !exists {
+		b.n++
+	}
+	return exists
+}
+
+func (b *Bloom) Add(data ...[]byte) {
+	if b == nil {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for _, d := range data {
+		b.add(d)
+	}
+}
+
+func (b *Bloom) AddString(data ...string) {
+	if b == nil {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for _, d := range data {
+		b.add([]byte(d))
+	}
+}
+
+// TestAndAdd adds data and returns true if it was (probably) already in filter
+func (b *Bloom) TestAndAdd(data []byte) bool {
+	if b == nil {
+		return false
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.add(data)
+}
+
+func (b *Bloom) TestAndAddString(data string) bool {
+	return b.TestAndAdd([]byte(data))
+}
+
+// Contains returns false if data is definitely not in filter
+func (b *Bloom) Contains(data []byte) bool {
+	if b == nil {
+		return false
+	}
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	h1, h2 := hashes(data)
+	for i := uint64(0); i < b.k; i++ {
+		loc := location(h1, h2, i, b.m)
+		if b.bits[loc/64]&(1<<(loc%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *Bloom) ContainsString(data string) bool {
+	return b.Contains([]byte(data))
+}
+
+// Length returns number of distinct items added (as seen by filter).
+// After Merge it is an upper bound, items added to both filters are counted twice.
+func (b *Bloom) Length() uint64 {
+	if b == nil {
+		return 0
+	}
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.n
+}
+
+// FalsePositiveRate returns estimated false positive rate for current fill
+func (b *Bloom) FalsePositiveRate() float64 {
+	if b == nil {
+		return 0
+	}
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return math.Pow(1-math.Exp(-float64(b.k*b.n)/float64(b.m)), float64(b.k))
+}
+
+func (b *Bloom) Clear() {
+	if b == nil {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.bits = make([]uint64, len(b.bits))
+	b.n = 0
+}
+
+// Merge adds all items of other filter, filters must have the same size
+func (b *Bloom) Merge(other *Bloom) error {
+	if b == nil || other == nil {
+		return ErrNilFilter
+	}
+	if b == other {
+		return nil
+	}
+
+	// other is copied before b is locked, so concurrent other.Merge(b) can't deadlock
+	d := other.snapshot()
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.m != d.M || b.k != d.K {
+		return ErrIncompatible
+	}
+	for i, w := range d.Bits {
+		b.bits[i] |= w
+	}
+	b.n += d.N
+
+	return nil
+}
+
+// snapshot returns copy of filter state
+func (b *Bloom) snapshot() bloomData {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return bloomData{M: b.m, K: b.k, N: b.n, Bits: append([]uint64(nil), b.bits...)}
+}
+
+type bloomData struct {
+	M    uint64
+	K    uint64
+	N    uint64
+	Bits []uint64
+}
+
+func (b *Bloom) marshal(m types.MarshalFunc) ([]byte, error) {
+	if b == nil {
+		return nil, ErrNilFilter
+	}
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return m(bloomData{M: b.m, K: b.k, N: b.n, Bits: b.bits})
+}
+
+func (b *Bloom) unmarshal(um types.UnmarshalFunc, data []byte) error {
+	if b == nil {
+		return ErrNilFilter
+	}
+
+	var d bloomData
+	if err := um(data, &d); err != nil {
+		return err
+	}
+	if d.M == 0 || d.K == 0 || uint64(len(d.Bits)) != (d.M+63)/64 {
+		return ErrIncompatible
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.m, b.k, b.n, b.bits = d.M, d.K, d.N, d.Bits
+	return nil
+}
+
+func (b *Bloom) MarshalJSON() ([]byte, error) {
+	return b.marshal(json.Marshal)
+}
+
+func (b *Bloom) UnmarshalJSON(data []byte) error {
+	return b.unmarshal(json.Unmarshal, data)
+}
+
+func (b *Bloom) MarshalCBOR() ([]byte, error) {
+	return b.marshal(cbor.Marshal)
+}
+
+func (b *Bloom) UnmarshalCBOR(data []byte) error {
+	return b.unmarshal(cbor.Unmarshal, data)
+}
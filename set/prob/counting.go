@@ -0,0 +1,239 @@
+package prob
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/timoni-io/go-utils"
+	"github.com/timoni-io/go-utils/types"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// --- Counting Bloom filter, supports removing items ---
+
+type CountingBloom struct {
+	lock     *utils.Lock
+	counters []uint8
+	k        uint64 // hash functions count
+	n        uint64 // items count
+}
+
+// NewCountingBloom creates filter sized for n items with given false positive rate.
+// Every cell is 8 bit counter, saturated counters are never decremented.
+func NewCountingBloom(n uint64, fpRate float64) *CountingBloom {
+	m, k := optimal(n, fpRate)
+	return &CountingBloom{
+		counters: make([]uint8, m),
+		k:        k,
+	}
+}
+
+func NewSafeCountingBloom(n uint64, fpRate float64) *CountingBloom {
+	b := NewCountingBloom(n, fpRate)
+	b.lock = &utils.Lock{}
+	return b
+}
+
+func (b *CountingBloom) contains(data []byte) bool {
+	h1, h2 := hashes(data)
+	m := uint64(len(b.counters))
+	for i := uint64(0); i < b.k; i++ {
+		if b.counters[location(h1, h2, i, m)] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *CountingBloom) Add(data ...[]byte) {
+	if b == nil {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	m := uint64(len(b.counters))
+	for _, d := range data {
+		h1, h2 := hashes(d)
+		for i := uint64(0); i < b.k; i++ {
+			loc := location(h1, h2, i, m)
+			if b.counters[loc] < math.MaxUint8 {
+				b.counters[loc]++
+			}
+		}
+		b.n++
+	}
+}
+
+func (b *CountingBloom) AddString(data ...string) {
+	for _, d := range data {
+		b.Add([]byte(d))
+	}
+}
+
+// Remove removes data from filter, returns false if data was not in filter.
+// Removing items which were never added may cause false negatives.
+func (b *CountingBloom) Remove(data []byte) bool {
+	if b == nil {
+		return false
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.contains(data) {
+		return false
+	}
+
+	h1, h2 := hashes(data)
+	m := uint64(len(b.counters))
+	for i := uint64(0); i < b.k; i++ {
+		loc := location(h1, h2, i, m)
+		if b.counters[loc] < math.MaxUint8 {
+			b.counters[loc]--
+		}
+	}
+	if b.n > 0 {
+		b.n--
+	}
+
+	return true
+}
+
+func (b *CountingBloom) RemoveString(data string) bool {
+	return b.Remove([]byte(data))
+}
+
+// Contains returns false if data is definitely not in filter
+func (b *CountingBloom) Contains(data []byte) bool {
+	if b == nil {
+		return false
+	}
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.contains(data)
+}
+
+func (b *CountingBloom) ContainsString(data string) bool {
+	return b.Contains([]byte(data))
+}
+
+// Length returns number of items in filter.
+// Merge adds counters, so items added to both filters are counted twice, as if added twice.
+func (b *CountingBloom) Length() uint64 {
+	if b == nil {
+		return 0
+	}
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.n
+}
+
+func (b *CountingBloom) Clear() {
+	if b == nil {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.counters = make([]uint8, len(b.counters))
+	b.n = 0
+}
+
+// Merge adds all items of other filter, filters must have the same size
+func (b *CountingBloom) Merge(other *CountingBloom) error {
+	if b == nil || other == nil {
+		return ErrNilFilter
+	}
+	if b == other {
+		return nil
+	}
+
+	// Merge never holds locks of both filters, counters of other are copied first
+	d := other.snapshot()
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if len(b.counters) != len(d.Counters) || b.k != d.K {
+		return ErrIncompatible
+	}
+	for i, c := range d.Counters {
+		if sum := uint16(b.counters[i]) + uint16(c); sum < math.MaxUint8 {
+			b.counters[i] = uint8(sum)
+		} else {
+			b.counters[i] = math.MaxUint8
+		}
+	}
+	b.n += d.N
+
+	return nil
+}
+
+// snapshot returns copy of filter state
+func (b *CountingBloom) snapshot() countingData {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return countingData{K: b.k, N: b.n, Counters: append([]uint8(nil), b.counters...)}
+}
+
+type countingData struct {
+	K        uint64
+	N        uint64
+	Counters []uint8
+}
+
+func (b *CountingBloom) marshal(m types.MarshalFunc) ([]byte, error) {
+	if b == nil {
+		return nil, ErrNilFilter
+	}
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return m(countingData{K: b.k, N: b.n, Counters: b.counters})
+}
+
+func (b *CountingBloom) unmarshal(um types.UnmarshalFunc, data []byte) error {
+	if b == nil {
+		return ErrNilFilter
+	}
+
+	var d countingData
+	if err := um(data, &d); err != nil {
+		return err
+	}
+	if d.K == 0 || len(d.Counters) == 0 {
+		return ErrIncompatible
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.k, b.n, b.counters = d.K, d.N, d.Counters
+	return nil
+}
+
+func (b *CountingBloom) MarshalJSON() ([]byte, error) {
+	return b.marshal(json.Marshal)
+}
+
+func (b *CountingBloom) UnmarshalJSON(data []byte) error {
+	return b.unmarshal(json.Unmarshal, data)
+}
+
+func (b *CountingBloom) MarshalCBOR() ([]byte, error) {
+	return b.marshal(cbor.Marshal)
+}
+
+func (b *CountingBloom) UnmarshalCBOR(data []byte) error {
+	return b.unmarshal(cbor.Unmarshal, data)
+}
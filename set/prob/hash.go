@@ -0,0 +1,65 @@
+package prob
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	ErrNilFilter    = errors.New("filter is nil")
+	ErrIncompatible = errors.New("filters are incompatible")
+)
+
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// fnv-1a 64 without allocations
+func fnv64a(data []byte) uint64 {
+	h := uint64(fnvOffset)
+	for _, c := range data {
+		h ^= uint64(c)
+		h *= fnvPrime
+	}
+	return h
+}
+
+// murmur3 finalizer, spreads fnv bits over whole word
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// hashes returns two independent hashes used for double hashing
+func hashes(data []byte) (uint64, uint64) {
+	h := fnv64a(data)
+	return mix(h), mix(h^0x9e3779b97f4a7c15) | 1
+}
+
+// optimal returns bits count and hash functions count
+// for n expected items and fpRate false positive rate
+func optimal(n uint64, fpRate float64) (m, k uint64) {
+	if n == 0 {
+		n = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+
+	m = uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	k = uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k == 0 {
+		k = 1
+	}
+	return m, k
+}
+
+// location of i-th hash in filter of m cells
+func location(h1, h2, i, m uint64) uint64 {
+	return (h1 + i*h2) % m
+}
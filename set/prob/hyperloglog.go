@@ -0,0 +1,211 @@
+package prob
+
+import (
+	"encoding/json"
+	"math"
+	"math/bits"
+
+	"github.com/timoni-io/go-utils"
+	"github.com/timoni-io/go-utils/types"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// --- HyperLogLog cardinality estimator ---
+
+const (
+	MinPrecision = 4
+	MaxPrecision = 18
+)
+
+type HyperLogLog struct {
+	lock      *utils.Lock
+	registers []uint8
+	p         uint8 // precision, registers count is 2^p
+}
+
+// NewHyperLogLog creates estimator with 2^precision registers,
+// standard error is about 1.04/sqrt(2^precision)
+func NewHyperLogLog(precision uint8) *HyperLogLog {
+	if precision < MinPrecision {
+		precision = MinPrecision
+	}
+	if precision > MaxPrecision {
+		precision = MaxPrecision
+	}
+
+	return &HyperLogLog{
+		registers: make([]uint8, 1<<precision),
+		p:         precision,
+	}
+}
+
+func NewSafeHyperLogLog(precision uint8) *HyperLogLog {
+	h := NewHyperLogLog(precision)
+	h.lock = &utils.Lock{}
+	return h
+}
+
+func (h *HyperLogLog) Add(data ...[]byte) {
+	if h == nil {
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for _, d := range data {
+		x := mix(fnv64a(d))
+		idx := x >> (64 - h.p)
+		// rank of first set bit in remaining bits
+		rank := uint8(bits.LeadingZeros64(x<<h.p|1<<(h.p-1))) + 1
+		if rank > h.registers[idx] {
+			h.registers[idx] = rank
+		}
+	}
+}
+
+func (h *HyperLogLog) AddString(data ...string) {
+	for _, d := range data {
+		h.Add([]byte(d))
+	}
+}
+
+// Count returns estimated number of distinct items
+func (h *HyperLogLog) Count() uint64 {
+	if h == nil {
+		return 0
+	}
+
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	estimate := alpha(m) * m * m / sum
+
+	// small range correction
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	return uint64(estimate + 0.5)
+}
+
+func alpha(m float64) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/m)
+}
+
+func (h *HyperLogLog) Clear() {
+	if h == nil {
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.registers = make([]uint8, len(h.registers))
+}
+
+// Merge adds all items of other estimator, precisions must be equal
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if h == nil || other == nil {
+		return ErrNilFilter
+	}
+	if h == other {
+		return nil
+	}
+
+	// registers of other are read under its lock only, so merges in both directions can run at once
+	d := other.snapshot()
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.p != d.P {
+		return ErrIncompatible
+	}
+	for i, r := range d.Registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+
+	return nil
+}
+
+// snapshot returns copy of estimator state
+func (h *HyperLogLog) snapshot() hyperLogLogData {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return hyperLogLogData{P: h.p, Registers: append([]uint8(nil), h.registers...)}
+}
+
+type hyperLogLogData struct {
+	P         uint8
+	Registers []uint8
+}
+
+func (h *HyperLogLog) marshal(m types.MarshalFunc) ([]byte, error) {
+	if h == nil {
+		return nil, ErrNilFilter
+	}
+
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return m(hyperLogLogData{P: h.p, Registers: h.registers})
+}
+
+func (h *HyperLogLog) unmarshal(um types.UnmarshalFunc, data []byte) error {
+	if h == nil {
+		return ErrNilFilter
+	}
+
+	var d hyperLogLogData
+	if err := um(data, &d); err != nil {
+		return err
+	}
+	if d.P < MinPrecision || d.P > MaxPrecision || len(d.Registers) != 1<<d.P {
+		return ErrIncompatible
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.p, h.registers = d.P, d.Registers
+	return nil
+}
+
+func (h *HyperLogLog) MarshalJSON() ([]byte, error) {
+	return h.marshal(json.Marshal)
+}
+
+func (h *HyperLogLog) UnmarshalJSON(data []byte) error {
+	return h.unmarshal(json.Unmarshal, data)
+}
+
+func (h *HyperLogLog) MarshalCBOR() ([]byte, error) {
+	return h.marshal(cbor.Marshal)
+}
+
+func (h *HyperLogLog) UnmarshalCBOR(data []byte) error {
+	return h.unmarshal(cbor.Unmarshal, data)
+}
@@ -0,0 +1,168 @@
+package prob
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestBloom(t *testing.T) {
+	b := NewBloom(1000, 0.01)
+
+	for i := 0; i < 1000; i++ {
+		b.AddString(fmt.Sprint(i))
+	}
+
+	for i := 0; i < 1000; i++ {
+		if !b.ContainsString(fmt.Sprint(i)) {
+			t.Fatalf("false negative for %d", i)
+		}
+	}
+
+	fp := 0
+	for i := 1000; i < 11000; i++ {
+		if b.ContainsString(fmt.Sprint(i)) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / 10000; rate > 0.03 {
+		t.Errorf("false positive rate too high %.4f", rate)
+	}
+}
+
+func TestBloomMerge(t *testing.T) {
+	a := NewBloom(100, 0.01)
+	b := NewBloom(100, 0.01)
+	a.AddString("a")
+	b.AddString("b")
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if !a.ContainsString("a") || !a.ContainsString("b") {
+		t.Fail()
+	}
+
+	if a.Merge(NewBloom(1000, 0.01)) != ErrIncompatible {
+		t.Error("merged incompatible filters")
+	}
+}
+
+func TestMergeConcurrent(t *testing.T) {
+	a, b := NewSafeBloom(10000, 0.01), NewSafeBloom(10000, 0.01)
+	ha, hb := NewSafeHyperLogLog(14), NewSafeHyperLogLog(14)
+	ca, cb := NewSafeCountingBloom(10000, 0.01), NewSafeCountingBloom(10000, 0.01)
+
+	done := make(chan struct{}, 2)
+	go func() {
+		for i := 0; i < 200; i++ {
+			b.Merge(a)
+			hb.Merge(ha)
+			cb.Merge(ca)
+		}
+		done <- struct{}{}
+	}()
+	go func() {
+		for i := 0; i < 200; i++ {
+			a.Merge(b)
+			ha.Merge(hb)
+			ca.Merge(cb)
+		}
+		done <- struct{}{}
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("concurrent merges deadlocked")
+		}
+	}
+}
+
+func TestMergeUnmarshal(t *testing.T) {
+	a, b := NewSafeBloom(100, 0.01), NewSafeBloom(100, 0.01)
+	data, _ := json.Marshal(a)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			json.Unmarshal(data, a)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if err := b.Merge(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
+
+func TestBloomMarshal(t *testing.T) {
+	b := NewSafeBloom(100, 0.01)
+	b.AddString("x")
+
+	for _, codec := range []struct {
+		m  func(any) ([]byte, error)
+		um func([]byte, any) error
+	}{{json.Marshal, json.Unmarshal}, {cbor.Marshal, cbor.Unmarshal}} {
+		data, err := codec.m(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := &Bloom{}
+		if err := codec.um(data, out); err != nil {
+			t.Fatal(err)
+		}
+		if !out.ContainsString("x") || out.Length() != 1 {
+			t.Fail()
+		}
+	}
+}
+
+func TestCountingBloom(t *testing.T) {
+	b := NewCountingBloom(100, 0.01)
+	b.AddString("x", "y")
+
+	if !b.ContainsString("x") {
+		t.Fail()
+	}
+	if !b.RemoveString("x") {
+		t.Error("remove failed")
+	}
+	if b.ContainsString("x") {
+		t.Error("x still in filter")
+	}
+	if !b.ContainsString("y") || b.Length() != 1 {
+		t.Fail()
+	}
+}
+
+func TestHyperLogLog(t *testing.T) {
+	h := NewHyperLogLog(14)
+	other := NewHyperLogLog(14)
+
+	for i := 0; i < 50000; i++ {
+		h.AddString(fmt.Sprint(i))
+		other.AddString(fmt.Sprint(i + 50000))
+	}
+
+	check := func(expected float64) {
+		count := float64(h.Count())
+		if count < expected*0.97 || count > expected*1.03 {
+			t.Errorf("estimate %.0f too far from %.0f", count, expected)
+		}
+	}
+
+	check(50000)
+
+	if err := h.Merge(other); err != nil {
+		t.Fatal(err)
+	}
+	check(100000)
+}
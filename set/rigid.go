@@ -1,44 +1,426 @@
 package set
 
 import (
+	"encoding/json"
+	"fmt"
+	"time"
+
 	"github.com/timoni-io/go-utils"
-	"github.com/timoni-io/go-utils/slice"
+	"github.com/timoni-io/go-utils/types"
 
+	"github.com/fxamacker/cbor/v2"
 	"golang.org/x/exp/constraints"
 )
 
+// --- Rigid is a bounded dedup window keeping last unique items ---
+
+type EvictReason uint8
+
+const (
+	// item was pushed out by newer item
+	EvictCapacity EvictReason = iota
+	// item is older than window TTL
+	EvictExpired
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictExpired:
+		return "expired"
+	}
+	return fmt.Sprintf("EvictReason(%d)", r)
+}
+
+type rigidItem[T comparable] struct {
+	value T
+	added time.Time
+}
+
 type Rigid[T comparable, S constraints.Unsigned] struct {
-	slice.Rigid[T, S]
-	set  Set[T]
 	lock *utils.Lock
+
+	// ring buffer in arrival order
+	items []rigidItem[T]
+	head  int
+	count int
+
+	// value to time it was added
+	index   map[T]time.Time
+	ttl     time.Duration
+	onEvict func(v T, reason EvictReason)
 }
 
+// NewRigid creates window for last size unique items, not safe for concurrent use
 func NewRigid[T comparable, S constraints.Unsigned](size S) *Rigid[T, S] {
+	if size == 0 {
+		size = 1
+	}
+
 	return &Rigid[T, S]{
-		Rigid: *slice.NewRigid[T](size),
-		set:   *New[T](),
+		items: make([]rigidItem[T], size),
+		index: make(map[T]time.Time, size),
 	}
 }
 
+// NewSafeRigid creates window for last size unique items, safe for concurrent use
 func NewSafeRigid[T comparable, S constraints.Unsigned](size S) *Rigid[T, S] {
-	return &Rigid[T, S]{
-		Rigid: *slice.NewRigid[T](size),
-		set:   *New[T](),
-		lock:  &utils.Lock{},
+	r := NewRigid[T](size)
+	r.lock = &utils.Lock{}
+	return r
+}
+
+// TTL sets time window, items older than ttl are evicted. Zero disables expiration.
+func (r *Rigid[T, S]) TTL(ttl time.Duration) *Rigid[T, S] {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.ttl = ttl
+	return r
+}
+
+// OnEvict sets callback called for every evicted item.
+// Callback is called after the lock is released, so it may use the Rigid.
+func (r *Rigid[T, S]) OnEvict(fn func(v T, reason EvictReason)) *Rigid[T, S] {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.onEvict = fn
+	return r
+}
+
+type evicted[T comparable] struct {
+	value  T
+	reason EvictReason
+}
+
+// notify calls fn captured under the lock for evicted items
+func notify[T comparable](fn func(v T, reason EvictReason), list []evicted[T]) {
+	if fn == nil {
+		return
+	}
+	for _, e := range list {
+		fn(e.value, e.reason)
+	}
+}
+
+// pop removes the oldest item, lock must be held
+func (r *Rigid[T, S]) pop(reason EvictReason, list []evicted[T]) []evicted[T] {
+	item := r.items[r.head]
+	r.items[r.head] = rigidItem[T]{}
+	r.head = (r.head + 1) % len(r.items)
+	r.count--
+	delete(r.index, item.value)
+
+	if r.onEvict != nil {
+		list = append(list, evicted[T]{value: item.value, reason: reason})
+	}
+	return list
+}
+
+// expire removes items outside of time window, lock must be held
+func (r *Rigid[T, S]) expire(now time.Time, list []evicted[T]) []evicted[T] {
+	if r.ttl <= 0 {
+		return list
+	}
+
+	for r.count > 0 && now.Sub(r.items[r.head].added) >= r.ttl {
+		list = r.pop(EvictExpired, list)
+	}
+	return list
+}
+
+// stale returns number of the oldest items outside of time window, read lock must be held
+func (r *Rigid[T, S]) stale(now time.Time) int {
+	if r.ttl <= 0 {
+		return 0
+	}
+
+	n := 0
+	for n < r.count && now.Sub(r.items[(r.head+n)%len(r.items)].added) >= r.ttl {
+		n++
+	}
+	return n
+}
+
+// add adds single value, lock must be held
+func (r *Rigid[T, S]) add(now time.Time, v T, list []evicted[T]) (bool, []evicted[T]) {
+	if r.items == nil {
+		r.items = make([]rigidItem[T], 1)
+	}
+	if r.index == nil {
+		r.index = map[T]time.Time{}
+	}
+
+	if _, exists := r.index[v]; exists {
+		return false, list
+	}
+
+	if r.count == len(r.items) {
+		list = r.pop(EvictCapacity, list)
+	}
+
+	r.items[(r.head+r.count)%len(r.items)] = rigidItem[T]{value: v, added: now}
+	r.count++
+	r.index[v] = now
+
+	return true, list
+}
+
+// Add adds values which are not already in the window
+func (r *Rigid[T, S]) Add(values ...T) {
+	if r == nil {
+		return
+	}
+
+	var list []evicted[T]
+	now := time.Now()
+
+	r.lock.Lock()
+	list = r.expire(now, list)
+	for _, v := range values {
+		_, list = r.add(now, v, list)
+	}
+	fn := r.onEvict
+	r.lock.Unlock()
+
+	notify(fn, list)
+}
+
+// TestAndAdd adds value and returns true if it was already in the window
+func (r *Rigid[T, S]) TestAndAdd(v T) bool {
+	if r == nil {
+		return false
+	}
+
+	var list []evicted[T]
+	now := time.Now()
+
+	r.lock.Lock()
+	list = r.expire(now, list)
+	added, list := r.add(now, v, list)
+	fn := r.onEvict
+	r.lock.Unlock()
+
+	notify(fn, list)
+	return !added
+}
+
+// Contains returns true if value is in the window
+func (r *Rigid[T, S]) Contains(v T) bool {
+	if r == nil {
+		return false
+	}
+
+	r.expireStale()
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	added, exists := r.index[v]
+	return exists && (r.ttl <= 0 || time.Since(added) < r.ttl)
+}
+
+// Len returns number of items in the window
+func (r *Rigid[T, S]) Len() int {
+	if r == nil {
+		return 0
 	}
+
+	r.expireStale()
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.count - r.stale(time.Now())
+}
+
+// LockStats returns lock contention stats, collected only in lock debug mode
+func (r *Rigid[T, S]) LockStats() utils.LockStats {
+	if r == nil {
+		return utils.LockStats{}
+	}
+	return r.lock.Stats()
+}
+
+// List returns items in arrival order, oldest first
+func (r *Rigid[T, S]) List() []T {
+	if r == nil {
+		return nil
+	}
+
+	r.expireStale()
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.list(r.stale(time.Now()))
+}
+
+// GetAll returns items in arrival order, oldest first, same as List
+func (r *Rigid[T, S]) GetAll() []T {
+	return r.List()
+}
+
+// Get returns copy of idx-th item counting from the oldest, nil if out of range
+func (r *Rigid[T, S]) Get(idx S) *T {
+	if r == nil {
+		return nil
+	}
+
+	r.expireStale()
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	from := r.stale(time.Now())
+	if uint64(idx) >= uint64(r.count-from) {
+		return nil
+	}
+	v := r.items[(r.head+from+int(idx))%len(r.items)].value
+	return &v
+}
+
+// GetLast returns up to amount newest items, oldest first
+func (r *Rigid[T, S]) GetLast(amount S) []T {
+	if r == nil {
+		return nil
+	}
+
+	r.expireStale()
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	from := r.stale(time.Now())
+	if uint64(amount) < uint64(r.count-from) {
+		from = r.count - int(amount)
+	}
+	return r.list(from)
 }
 
-func (r *Rigid[T, S]) Add(x ...T) {
-	toAdd := make([]T, 0, len(x))
+// expireStale evicts expired items, write lock is taken only if there are any.
+// Items can expire again before the read lock is taken, so readers skip stale items too.
+func (r *Rigid[T, S]) expireStale() {
+	r.lock.RLock()
+	stale := r.stale(time.Now())
+	r.lock.RUnlock()
+
+	if stale > 0 {
+		r.Expire()
+	}
+}
+
+// list returns items from the from-th oldest in arrival order, read lock must be held
+func (r *Rigid[T, S]) list(from int) []T {
+	values := make([]T, r.count-from)
+	for i := range values {
+		values[i] = r.items[(r.head+from+i)%len(r.items)].value
+	}
+	return values
+}
+
+// Expire evicts items outside of time window.
+// Expiration is also done on every other call, use it to get callbacks without traffic.
+func (r *Rigid[T, S]) Expire() {
+	if r == nil {
+		return
+	}
+
+	r.lock.Lock()
+	list := r.expire(time.Now(), nil)
+	fn := r.onEvict
+	r.lock.Unlock()
+
+	notify(fn, list)
+}
+
+// Take returns items in arrival order and clears the window, no callbacks are called
+func (r *Rigid[T, S]) Take() []T {
+	if r == nil {
+		return nil
+	}
 
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	for _, v := range x {
-		if !r.set.Contains(v) {
-			toAdd = append(toAdd, v)
-		}
+	values := r.list(0)
+	r.clear()
+
+	return values
+}
+
+// Clear removes all items without calling callbacks
+func (r *Rigid[T, S]) Clear() {
+	if r == nil {
+		return
 	}
 
-	r.set.Remove(r.Rigid.Add(toAdd...)...)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.clear()
+}
+
+func (r *Rigid[T, S]) clear() {
+	r.items = make([]rigidItem[T], len(r.items))
+	r.head = 0
+	r.count = 0
+	r.index = make(map[T]time.Time, len(r.items))
+}
+
+func (r *Rigid[T, S]) String() string {
+	if r == nil {
+		return "[]"
+	}
+
+	return fmt.Sprint(r.List())
+}
+
+func (r *Rigid[T, S]) marshal(m types.MarshalFunc) ([]byte, error) {
+	if r == nil {
+		return nil, types.ErrNilSet
+	}
+
+	return m(r.List())
+}
+
+// unmarshal replaces items, restored items are treated as added now.
+// No callbacks are called, values which don't fit push out the older ones.
+func (r *Rigid[T, S]) unmarshal(um types.UnmarshalFunc, data []byte) error {
+	if r == nil {
+		return types.ErrNilSet
+	}
+
+	var values []T
+	err := um(data, &values)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.items) == 0 && len(values) > 0 {
+		// zero value Rigid, size window to fit all values
+		r.items = make([]rigidItem[T], len(values))
+	}
+	r.clear()
+
+	now := time.Now()
+	for _, v := range values {
+		r.add(now, v, nil)
+	}
+
+	return nil
+}
+
+func (r *Rigid[T, S]) MarshalJSON() ([]byte, error) {
+	return r.marshal(json.Marshal)
+}
+
+func (r *Rigid[T, S]) UnmarshalJSON(data []byte) error {
+	return r.unmarshal(json.Unmarshal, data)
+}
+
+func (r *Rigid[T, S]) MarshalCBOR() ([]byte, error) {
+	return r.marshal(cbor.Marshal)
+}
+
+func (r *Rigid[T, S]) UnmarshalCBOR(data []byte) error {
+	return r.unmarshal(cbor.Unmarshal, data)
 }
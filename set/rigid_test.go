@@ -0,0 +1,127 @@
+package set
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRigidCapacity(t *testing.T) {
+	evicted := []int{}
+	r := NewSafeRigid[int](uint8(3)).OnEvict(func(v int, reason EvictReason) {
+		if reason != EvictCapacity {
+			t.Errorf("invalid reason %s", reason)
+		}
+		evicted = append(evicted, v)
+	})
+
+	r.Add(1, 2, 2, 3, 4)
+
+	if r.Len() != 3 {
+		t.Errorf("invalid len %d", r.Len())
+	}
+	if r.Contains(1) || !r.Contains(4) {
+		t.Fail()
+	}
+	if list := r.List(); list[0] != 2 || list[2] != 4 {
+		t.Errorf("invalid order %v", list)
+	}
+	if len(evicted) != 1 || evicted[0] != 1 {
+		t.Errorf("invalid evicted %v", evicted)
+	}
+}
+
+func TestRigidGet(t *testing.T) {
+	r := NewRigid[string](uint8(3))
+	r.Add("a", "b", "c", "d")
+
+	if v := r.Get(0); v == nil || *v != "b" {
+		t.Errorf("invalid oldest item %v", v)
+	}
+	if r.Get(3) != nil {
+		t.Error("out of range item returned")
+	}
+	if last := r.GetLast(2); len(last) != 2 || last[0] != "c" || last[1] != "d" {
+		t.Errorf("invalid last items %v", last)
+	}
+	if all := r.GetAll(); len(all) != 3 || all[0] != "b" {
+		t.Errorf("invalid items %v", all)
+	}
+}
+
+func TestRigidTTL(t *testing.T) {
+	expired := 0
+	r := NewRigid[string](uint(10)).TTL(10 * time.Millisecond).OnEvict(func(v string, reason EvictReason) {
+		if reason == EvictExpired {
+			expired++
+		}
+	})
+
+	if r.TestAndAdd("x") {
+		t.Error("x reported as duplicate")
+	}
+	if !r.TestAndAdd("x") {
+		t.Error("x not deduplicated")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if r.Contains("x") {
+		t.Error("x not expired")
+	}
+	if expired != 1 {
+		t.Errorf("invalid expired count %d", expired)
+	}
+}
+
+func TestRigidMarshal(t *testing.T) {
+	r := NewRigid[string](uint(3))
+	r.Add("a", "b")
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `["a","b"]` {
+		t.Error(string(data))
+	}
+
+	out := &Rigid[string, uint]{}
+	if err := json.Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 2 || !out.Contains("a") {
+		t.Fail()
+	}
+}
+
+func TestRigidUnmarshalNoEvict(t *testing.T) {
+	evicted := 0
+	r := NewSafeRigid[string](uint(2)).OnEvict(func(v string, reason EvictReason) { evicted++ })
+
+	if err := json.Unmarshal([]byte(`["a","b","c"]`), r); err != nil {
+		t.Fatal(err)
+	}
+	if evicted != 0 {
+		t.Errorf("callback called %d times during decode", evicted)
+	}
+	if list := r.List(); len(list) != 2 || list[0] != "b" || list[1] != "c" {
+		t.Errorf("invalid items %v", list)
+	}
+}
+
+func TestRigidOnEvictConcurrent(t *testing.T) {
+	r := NewSafeRigid[int](uint(1))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			r.OnEvict(func(v int, reason EvictReason) {})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		r.Add(i)
+	}
+	<-done
+}
@@ -1,6 +1,7 @@
 package set
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -31,6 +32,7 @@ func NewSafe[T comparable](data ...T) *Set[T] {
 	return set
 }
 
+// init must be called with lock held
 func (set *Set[T]) init() error {
 	if set == nil {
 		return types.ErrNilSet
@@ -43,39 +45,88 @@ func (set *Set[T]) init() error {
 }
 
 func (set *Set[T]) Add(values ...T) {
-	if set.init() != nil {
-		return
+	set.AddE(values...)
+}
+
+// AddE adds values, returns ErrNilSet if set is nil
+func (set *Set[T]) AddE(values ...T) error {
+	if set == nil {
+		return types.ErrNilSet
 	}
 
 	set.lock.Lock()
 	defer set.lock.Unlock()
 
-	for _, value := range values {
-		set.data[value] = void{}
-	}
+	set.add(values)
+	return nil
 }
 
 func (set *Set[T]) Delete(values ...T) {
+	set.DeleteE(values...)
+}
+
+// DeleteE deletes values, returns ErrNilSet if set is nil
+func (set *Set[T]) DeleteE(values ...T) error {
 	if set == nil {
-		return
+		return types.ErrNilSet
 	}
 
 	set.lock.Lock()
 	defer set.lock.Unlock()
 
-	for _, v := range values {
-		delete(set.data, v)
-	}
+	set.delete(values)
+	return nil
 }
 
 func (set *Set[T]) Remove(values ...T) {
-	if set.init() != nil {
-		return
+	set.Delete(values...)
+}
+
+// AddContext adds values, returns ctx error if lock is not acquired before ctx is done
+func (set *Set[T]) AddContext(ctx context.Context, values ...T) error {
+	if set == nil {
+		return types.ErrNilSet
 	}
 
-	set.lock.Lock()
+	if err := set.lock.LockContext(ctx); err != nil {
+		return err
+	}
 	defer set.lock.Unlock()
 
+	set.add(values)
+	return nil
+}
+
+// DeleteContext deletes values, returns ctx error if lock is not acquired before ctx is done
+func (set *Set[T]) DeleteContext(ctx context.Context, values ...T) error {
+	if set == nil {
+		return types.ErrNilSet
+	}
+
+	if err := set.lock.LockContext(ctx); err != nil {
+		return err
+	}
+	defer set.lock.Unlock()
+
+	set.delete(values)
+	return nil
+}
+
+// RemoveContext is DeleteContext
+func (set *Set[T]) RemoveContext(ctx context.Context, values ...T) error {
+	return set.DeleteContext(ctx, values...)
+}
+
+// add adds values, lock must be held
+func (set *Set[T]) add(values []T) {
+	set.init()
+	for _, value := range values {
+		set.data[value] = void{}
+	}
+}
+
+// delete deletes values, lock must be held
+func (set *Set[T]) delete(values []T) {
 	for _, value := range values {
 		delete(set.data, value)
 	}
@@ -93,20 +144,99 @@ func (set *Set[T]) Contains(value T) bool {
 	return exists
 }
 
+// Iter returns buffered channel with all values, channel is closed after last value
 func (set *Set[T]) Iter() <-chan T {
+	if set == nil {
+		out := make(chan T)
+		close(out)
+		return out
+	}
+
+	set.lock.RLock()
+	defer set.lock.RUnlock()
+
 	out := make(chan T, len(set.data))
+	for value := range set.data {
+		out <- value
+	}
+	close(out)
+
+	return out
+}
+
+// IterContext returns unbuffered channel with all values.
+// Read lock is held until all values are consumed or ctx is done,
+// so cancel ctx when stopping early.
+func (set *Set[T]) IterContext(ctx context.Context) <-chan T {
+	out := make(chan T)
+	if set == nil {
+		close(out)
+		return out
+	}
 
 	set.lock.RLock()
 	go func() {
+		defer close(out)
 		defer set.lock.RUnlock()
+
 		for value := range set.data {
-			out <- value
+			select {
+			case out <- value:
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 
 	return out
 }
 
+// ForEach calls fn for every value
+func (set *Set[T]) ForEach(fn func(v T)) {
+	set.Range(func(v T) bool {
+		fn(v)
+		return true
+	})
+}
+
+// Range calls fn for every value until fn returns false
+func (set *Set[T]) Range(fn func(v T) bool) {
+	if set == nil {
+		return
+	}
+
+	set.lock.RLock()
+	defer set.lock.RUnlock()
+
+	for value := range set.data {
+		if !fn(value) {
+			return
+		}
+	}
+}
+
+// RangeContext calls fn for every value until fn returns false or ctx is done.
+// Returns ctx error if iteration was interrupted by ctx.
+func (set *Set[T]) RangeContext(ctx context.Context, fn func(v T) bool) error {
+	if set == nil {
+		return nil
+	}
+
+	set.lock.RLock()
+	defer set.lock.RUnlock()
+
+	for value := range set.data {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !fn(value) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
 func (set *Set[T]) List() (list []T) {
 	if set == nil {
 		return
@@ -135,6 +265,14 @@ func (set *Set[T]) Length() int {
 	return len(set.data)
 }
 
+// LockStats returns lock contention stats, collected only in lock debug mode
+func (set *Set[T]) LockStats() utils.LockStats {
+	if set == nil {
+		return utils.LockStats{}
+	}
+	return set.lock.Stats()
+}
+
 func (set *Set[T]) String() string {
 	if set == nil {
 		return "[]"
@@ -148,9 +286,6 @@ func (set *Set[T]) marshal(m types.MarshalFunc) ([]byte, error) {
 		return nil, types.ErrNilSet
 	}
 
-	set.lock.RLock()
-	defer set.lock.RUnlock()
-
 	return m(set.List())
 }
 
@@ -169,7 +304,7 @@ func (set *Set[T]) unmarshal(um types.UnmarshalFunc, data []byte) error {
 	}
 
 	set.data = nil
-	set.Add(values...)
+	set.add(values)
 
 	return nil
 }
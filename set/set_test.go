@@ -0,0 +1,86 @@
+package set
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestIter(t *testing.T) {
+	s := NewSafe(1, 2, 3)
+
+	sum := 0
+	for v := range s.Iter() {
+		sum += v
+	}
+
+	if sum != 6 {
+		t.Errorf("invalid sum %d", sum)
+	}
+
+	// lock must be released
+	s.Add(4)
+	if s.Length() != 4 {
+		t.Fail()
+	}
+}
+
+func TestIterContext(t *testing.T) {
+	s := NewSafe(1, 2, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for range s.IterContext(ctx) {
+		break
+	}
+	cancel()
+
+	// lock must be released after cancel
+	s.Add(4)
+	if s.Length() != 4 {
+		t.Fail()
+	}
+}
+
+func TestRange(t *testing.T) {
+	s := New(1, 2, 3)
+
+	i := 0
+	s.Range(func(v int) bool {
+		i++
+		return false
+	})
+
+	if i != 1 {
+		t.Errorf("range not stopped, %d calls", i)
+	}
+}
+
+func TestRangeContext(t *testing.T) {
+	s := New(1, 2, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := s.RangeContext(ctx, func(v int) bool {
+		t.Error("fn called after cancel")
+		return true
+	})
+	if err != context.Canceled {
+		t.Errorf("invalid err %v", err)
+	}
+}
+
+func TestSafeUnmarshal(t *testing.T) {
+	s := NewSafe[int]()
+	if err := json.Unmarshal([]byte("[1,2]"), s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Length() != 2 || !s.Contains(1) || !s.Contains(2) {
+		t.Errorf("invalid set %v", s)
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil || (string(data) != "[1,2]" && string(data) != "[2,1]") {
+		t.Errorf("invalid json %s %v", data, err)
+	}
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+// SingleFlight suppresses duplicate calls, concurrent calls with the same key share one execution
+type SingleFlight[K comparable, T any] struct {
+	mu    sync.Mutex
+	calls map[K]*flight[T]
+}
+
+type flight[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+
+	// number of callers waiting for result, fn ctx is canceled when all of them leave
+	waiting int
+	shared  bool
+	cancel  context.CancelFunc
+}
+
+func NewSingleFlight[K comparable, T any]() *SingleFlight[K, T] {
+	return &SingleFlight[K, T]{calls: map[K]*flight[T]{}}
+}
+
+// Do calls fn once for all concurrent callers with the same key,
+// shared is true if result was given to multiple callers
+func (g *SingleFlight[K, T]) Do(key K, fn func() (T, error)) (v T, err error, shared bool) {
+	return g.DoContext(context.Background(), key, func(context.Context) (T, error) {
+		return fn()
+	})
+}
+
+// DoContext calls fn once for all concurrent callers with the same key.
+// Caller stops waiting when its ctx is done, fn ctx is canceled when all callers stopped waiting.
+// Panic in fn is returned as error to all callers.
+func (g *SingleFlight[K, T]) DoContext(ctx context.Context, key K, fn func(ctx context.Context) (T, error)) (v T, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[K]*flight[T]{}
+	}
+
+	f, ok := g.calls[key]
+	if ok {
+		f.waiting++
+		f.shared = true
+	} else {
+		fctx, cancel := context.WithCancel(context.Background())
+		f = &flight[T]{done: make(chan struct{}), waiting: 1, cancel: cancel}
+		g.calls[key] = f
+		go g.run(fctx, key, f, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		g.mu.Lock()
+		shared = f.shared
+		g.mu.Unlock()
+		return f.val, f.err, shared
+
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiting--
+		if f.waiting == 0 {
+			// nobody waits, next call starts new execution
+			f.cancel()
+			if g.calls[key] == f {
+				delete(g.calls, key)
+			}
+		}
+		shared = f.shared
+		g.mu.Unlock()
+		return v, ctx.Err(), shared
+	}
+}
+
+func (g *SingleFlight[K, T]) run(ctx context.Context, key K, f *flight[T], fn func(ctx context.Context) (T, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			f.err = CapturePanic(r)
+		}
+		f.cancel()
+
+		g.mu.Lock()
+		// key may be already forgotten and reused by other call
+		if g.calls[key] == f {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+
+		close(f.done)
+	}()
+
+	f.val, f.err = fn(ctx)
+}
+
+// Forget removes key, next call starts new execution instead of waiting for the running one
+func (g *SingleFlight[K, T]) Forget(key K) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.calls, key)
+}
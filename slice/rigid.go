@@ -1,16 +1,28 @@
 package slice
 
 import (
+	"context"
+
 	"github.com/timoni-io/go-utils"
+	"github.com/timoni-io/go-utils/errors"
 
 	"golang.org/x/exp/constraints"
 )
 
+var ErrNilRigid = errors.New(errors.Internal, "rigid is nil")
+
+// Rigid is a circular buffer with fixed memory.
+// When full, Add overwrites the oldest items, unless Reject mode is set.
 type Rigid[T any, S constraints.Unsigned] struct {
 	lock *utils.Lock
 
-	data []T
-	size S
+	data   []T
+	head   int
+	count  int
+	reject bool
+
+	// signals Pop that items are available
+	notify chan struct{}
 }
 
 func NewRigid[T any, S constraints.Unsigned](size S) *Rigid[T, S] {
@@ -19,23 +31,77 @@ func NewRigid[T any, S constraints.Unsigned](size S) *Rigid[T, S] {
 	}
 
 	return &Rigid[T, S]{
-		data: make([]T, 0, size),
-		size: size,
+		data:   make([]T, size),
+		notify: make(chan struct{}, 1),
 	}
 }
 
 func NewSafeRigid[T any, S constraints.Unsigned](size S) *Rigid[T, S] {
-	if size == 0 {
-		size = 1
+	r := NewRigid[T](size)
+	r.lock = &utils.Lock{}
+	return r
+}
+
+// Reject sets mode in which Add rejects new items when buffer is full
+func (r *Rigid[T, S]) Reject() *Rigid[T, S] {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.reject = true
+	return r
+}
+
+func (r *Rigid[T, S]) signal() {
+	select {
+	case r.notify <- struct{}{}:
+	default:
 	}
+}
 
-	return &Rigid[T, S]{
-		data: make([]T, 0, size),
-		size: size,
-		lock: &utils.Lock{},
+// at returns i-th item counting from the oldest, lock must be held
+func (r *Rigid[T, S]) at(i int) *T {
+	return &r.data[(r.head+i)%len(r.data)]
+}
+
+// list returns copy of items from oldest, lock must be held
+func (r *Rigid[T, S]) list(from int) []T {
+	out := make([]T, r.count-from)
+	for i := range out {
+		out[i] = *r.at(from + i)
 	}
+	return out
 }
 
+// init prepares zero value Rigid, lock must be held
+func (r *Rigid[T, S]) init() {
+	if r.data == nil {
+		r.data = make([]T, 1)
+	}
+	if r.notify == nil {
+		r.notify = make(chan struct{}, 1)
+	}
+}
+
+// add adds single item, lock must be held
+func (r *Rigid[T, S]) add(v T) (removed T, full bool) {
+	r.init()
+
+	if r.count == len(r.data) {
+		if r.reject {
+			return v, true
+		}
+
+		removed = r.data[r.head]
+		r.data[r.head] = v
+		r.head = (r.head + 1) % len(r.data)
+		return removed, true
+	}
+
+	*r.at(r.count) = v
+	r.count++
+	return removed, false
+}
+
+// Add adds items to buffer. Returns overwritten items, or in Reject mode items which didn't fit.
 func (r *Rigid[T, S]) Add(x ...T) (removed []T) {
 	if r == nil {
 		return
@@ -44,21 +110,136 @@ func (r *Rigid[T, S]) Add(x ...T) (removed []T) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	r.data = append(r.data, x...)
-	if S(len(r.data)) > r.size {
-		removed = r.data[:S(len(r.data))-r.size]
-		r.data = r.data[S(len(r.data))-r.size:]
+	return r.addAll(x)
+}
+
+// AddContext adds items to buffer, returns ctx error if lock is not acquired before ctx is done
+func (r *Rigid[T, S]) AddContext(ctx context.Context, x ...T) (removed []T, err error) {
+	if r == nil {
+		return nil, ErrNilRigid
+	}
+
+	if err := r.lock.LockContext(ctx); err != nil {
+		return nil, err
+	}
+	defer r.lock.Unlock()
+
+	return r.addAll(x), nil
+}
+
+// addAll adds items, lock must be held
+func (r *Rigid[T, S]) addAll(x []T) (removed []T) {
+	for _, v := range x {
+		if old, full := r.add(v); full {
+			removed = append(removed, old)
+		}
+	}
+
+	if r.count > 0 {
+		r.signal()
 	}
 
 	return removed
 }
 
+// Push adds single item, returns false if it was rejected
+func (r *Rigid[T, S]) Push(v T) bool {
+	if r == nil {
+		return false
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.push(v)
+}
+
+// PushContext adds single item, returns false if it was rejected
+// and ctx error if lock is not acquired before ctx is done
+func (r *Rigid[T, S]) PushContext(ctx context.Context, v T) (bool, error) {
+	if r == nil {
+		return false, ErrNilRigid
+	}
+
+	if err := r.lock.LockContext(ctx); err != nil {
+		return false, err
+	}
+	defer r.lock.Unlock()
+
+	return r.push(v), nil
+}
+
+// push adds single item, lock must be held
+func (r *Rigid[T, S]) push(v T) bool {
+	_, full := r.add(v)
+	r.signal()
+	return !full || !r.reject
+}
+
+// pop removes the oldest item, lock must be held
+func (r *Rigid[T, S]) pop() T {
+	var zero T
+	v := r.data[r.head]
+	r.data[r.head] = zero
+	r.head = (r.head + 1) % len(r.data)
+	r.count--
+	return v
+}
+
+// TryPop removes and returns the oldest item, returns false if buffer is empty
+func (r *Rigid[T, S]) TryPop() (v T, ok bool) {
+	if r == nil {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.init()
+	if r.count == 0 {
+		return
+	}
+
+	v = r.pop()
+	if r.count > 0 {
+		// wake next waiting consumer
+		r.signal()
+	}
+	return v, true
+}
+
+// Pop removes and returns the oldest item, waits until an item is available or ctx is done
+func (r *Rigid[T, S]) Pop(ctx context.Context) (T, error) {
+	if r == nil {
+		return *new(T), ErrNilRigid
+	}
+
+	for {
+		if v, ok := r.TryPop(); ok {
+			return v, nil
+		}
+
+		select {
+		case <-r.notify:
+		case <-ctx.Done():
+			return *new(T), ctx.Err()
+		}
+	}
+}
+
+// GetAll returns copy of all items from oldest to newest
 func (r *Rigid[T, S]) GetAll() []T {
+	if r == nil {
+		return nil
+	}
+
 	r.lock.RLock()
 	defer r.lock.RUnlock()
-	return r.data
+
+	return r.list(0)
 }
 
+// Get returns copy of idx-th item counting from the oldest, nil if out of range
 func (r *Rigid[T, S]) Get(idx S) *T {
 	if r == nil {
 		return nil
@@ -67,12 +248,15 @@ func (r *Rigid[T, S]) Get(idx S) *T {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
 
-	if idx > S(len(r.data)) {
-		idx = S(len(r.data) - 1)
+	if uint64(idx) >= uint64(r.count) {
+		return nil
 	}
-	return &r.data[idx]
+
+	v := *r.at(int(idx))
+	return &v
 }
 
+// GetLast returns up to amount newest items, from oldest to newest
 func (r *Rigid[T, S]) GetLast(amount S) []T {
 	if r == nil {
 		return nil
@@ -81,12 +265,13 @@ func (r *Rigid[T, S]) GetLast(amount S) []T {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
 
-	if amount > r.size {
-		amount = r.size
+	if uint64(amount) > uint64(r.count) {
+		amount = S(r.count)
 	}
-	return r.data[S(len(r.data))-amount:]
+	return r.list(r.count - int(amount))
 }
 
+// Take returns all items from oldest to newest and clears buffer
 func (r *Rigid[T, S]) Take() []T {
 	if r == nil {
 		return nil
@@ -95,7 +280,133 @@ func (r *Rigid[T, S]) Take() []T {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	v := r.data
-	r.data = make([]T, 0, r.size)
+	v := r.list(0)
+	r.clear()
 	return v
 }
+
+// TakeContext returns all items and clears buffer, returns ctx error if lock is not acquired before ctx is done
+func (r *Rigid[T, S]) TakeContext(ctx context.Context) ([]T, error) {
+	if r == nil {
+		return nil, ErrNilRigid
+	}
+
+	if err := r.lock.LockContext(ctx); err != nil {
+		return nil, err
+	}
+	defer r.lock.Unlock()
+
+	v := r.list(0)
+	r.clear()
+	return v, nil
+}
+
+func (r *Rigid[T, S]) Clear() {
+	if r == nil {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.clear()
+}
+
+// ClearContext removes all items, returns ctx error if lock is not acquired before ctx is done
+func (r *Rigid[T, S]) ClearContext(ctx context.Context) error {
+	if r == nil {
+		return ErrNilRigid
+	}
+
+	if err := r.lock.LockContext(ctx); err != nil {
+		return err
+	}
+	defer r.lock.Unlock()
+
+	r.clear()
+	return nil
+}
+
+func (r *Rigid[T, S]) clear() {
+	var zero T
+	for i := range r.data {
+		r.data[i] = zero
+	}
+	r.head = 0
+	r.count = 0
+}
+
+func (r *Rigid[T, S]) Len() int {
+	if r == nil {
+		return 0
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.count
+}
+
+// LockStats returns lock contention stats, collected only in lock debug mode
+func (r *Rigid[T, S]) LockStats() utils.LockStats {
+	if r == nil {
+		return utils.LockStats{}
+	}
+	return r.lock.Stats()
+}
+
+func (r *Rigid[T, S]) Cap() int {
+	if r == nil {
+		return 0
+	}
+
+	return len(r.data)
+}
+
+func (r *Rigid[T, S]) Full() bool {
+	if r == nil {
+		return false
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.count == len(r.data)
+}
+
+// Iter returns buffered channel with items from oldest to newest
+func (r *Rigid[T, S]) Iter() <-chan T {
+	values := r.GetAll()
+
+	out := make(chan T, len(values))
+	for _, v := range values {
+		out <- v
+	}
+	close(out)
+
+	return out
+}
+
+// ForEach calls fn for every item from oldest to newest
+func (r *Rigid[T, S]) ForEach(fn func(v T)) {
+	r.Range(func(v T) bool {
+		fn(v)
+		return true
+	})
+}
+
+// Range calls fn for every item from oldest to newest until fn returns false
+func (r *Rigid[T, S]) Range(fn func(v T) bool) {
+	if r == nil {
+		return
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for i := 0; i < r.count; i++ {
+		if !fn(*r.at(i)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package slice
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRigidOverwrite(t *testing.T) {
+	r := NewRigid[int](uint(3))
+
+	removed := r.Add(1, 2, 3, 4, 5)
+	if !Equal(removed, []int{1, 2}) {
+		t.Errorf("invalid removed %v", removed)
+	}
+	if all := r.GetAll(); !Equal(all, []int{3, 4, 5}) {
+		t.Errorf("invalid items %v", all)
+	}
+	if last := r.GetLast(10); !Equal(last, []int{3, 4, 5}) {
+		t.Errorf("invalid last %v", last)
+	}
+	if v := r.Get(0); v == nil || *v != 3 {
+		t.Error("invalid first item")
+	}
+	if r.Get(3) != nil {
+		t.Error("out of range item")
+	}
+}
+
+func TestRigidReject(t *testing.T) {
+	r := NewRigid[int](uint(2)).Reject()
+
+	if !r.Push(1) || !r.Push(2) || r.Push(3) {
+		t.Error("invalid push result")
+	}
+	if removed := r.Add(4); !Equal(removed, []int{4}) {
+		t.Errorf("invalid rejected %v", removed)
+	}
+	if all := r.GetAll(); !Equal(all, []int{1, 2}) {
+		t.Errorf("invalid items %v", all)
+	}
+}
+
+func TestRigidContext(t *testing.T) {
+	ctx := context.Background()
+	r := NewSafeRigid[int](uint(1)).Reject()
+
+	if ok, err := r.PushContext(ctx, 1); !ok || err != nil {
+		t.Errorf("unexpected result %v %v", ok, err)
+	}
+	if ok, err := r.PushContext(ctx, 2); ok || err != nil {
+		t.Errorf("unexpected result %v %v", ok, err)
+	}
+
+	r.lock.Lock()
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := r.ClearContext(tctx); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	r.lock.Unlock()
+
+	if err := r.ClearContext(ctx); err != nil || r.Len() != 0 {
+		t.Errorf("unexpected result %v %v", r.Len(), err)
+	}
+
+	var nilRigid *Rigid[int, uint]
+	if _, err := nilRigid.PushContext(ctx, 1); err != ErrNilRigid {
+		t.Errorf("expected ErrNilRigid, got %v", err)
+	}
+}
+
+func TestRigidPop(t *testing.T) {
+	r := NewSafeRigid[int](uint(2))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		r.Add(1)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	v, err := r.Pop(ctx)
+	if err != nil || v != 1 {
+		t.Errorf("invalid pop %d %v", v, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := r.Pop(ctx); err != context.DeadlineExceeded {
+		t.Errorf("invalid err %v", err)
+	}
+}
+
+func TestSPSCRigid(t *testing.T) {
+	r := NewSPSCRigid[int](uint(8))
+
+	go func() {
+		for i := 0; i < 1000; {
+			if r.Push(i) {
+				i++
+			} else {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < 1000; i++ {
+		v, err := r.Pop(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != i {
+			t.Fatalf("%d != %d", v, i)
+		}
+	}
+}
@@ -1,17 +1,28 @@
 package slice
 
 import (
+	"context"
 	"encoding/json"
+	"sort"
+
 	"github.com/timoni-io/go-utils"
+	"github.com/timoni-io/go-utils/channel"
+	"github.com/timoni-io/go-utils/errors"
 	"github.com/timoni-io/go-utils/types"
 
 	"github.com/fxamacker/cbor/v2"
 )
 
+var (
+	ErrNilSlice   = errors.New(errors.Internal, "slice is nil")
+	ErrOutOfRange = errors.New(errors.InvalidArgument, "index out of range")
+)
+
 type Slice[T any] struct {
 	lock     *utils.Lock
 	data     []T
 	capacity int
+	*channel.Hub[types.WatchMsg[int, T]]
 }
 
 func NewSlice[T any](capacity int) *Slice[T] {
@@ -29,55 +40,571 @@ func NewSafeSlice[T any](capacity int) *Slice[T] {
 	}
 }
 
-func (s *Slice[T]) Add(x ...T) {
+// Eventfull returns slice publishing INSERT and REMOVE events with item index.
+// Sort, Reverse and Commit don't publish events.
+func (s *Slice[T]) Eventfull(ctx context.Context, buf int) *Slice[T] {
 	s.lock.Lock()
 	defer s.lock.Unlock()
+	s.Hub = channel.NewHub[types.WatchMsg[int, T]](ctx, buf)
+	return s
+}
+
+// event returns message if slice is eventfull, lock must be held
+func (s *Slice[T]) event(events []types.WatchMsg[int, T], event types.EventType, idx int, v T) []types.WatchMsg[int, T] {
+	if s.Hub == nil {
+		return events
+	}
+	return append(events, types.WatchMsg[int, T]{
+		Event: event,
+		Item:  types.Item[int, T]{Key: idx, Value: v},
+	})
+}
+
+func (s *Slice[T]) publish(events []types.WatchMsg[int, T]) {
+	for _, e := range events {
+		s.Hub.Broadcast(e)
+	}
+}
+
+// publishReversed publishes removals from the last one, so indexes are valid when applied in order
+func (s *Slice[T]) publishReversed(events []types.WatchMsg[int, T]) {
+	for i := len(events) - 1; i >= 0; i-- {
+		s.Hub.Broadcast(events[i])
+	}
+}
+
+func (s *Slice[T]) Add(x ...T) {
+	s.AddE(x...)
+}
+
+// AddE appends items, returns ErrNilSlice if slice is nil
+func (s *Slice[T]) AddE(x ...T) error {
+	if s == nil {
+		return ErrNilSlice
+	}
+
+	s.lock.Lock()
+	events := s.add(x)
+	s.lock.Unlock()
+
+	s.publish(events)
+	return nil
+}
+
+// AddContext appends items, returns ctx error if lock is not acquired before ctx is done
+func (s *Slice[T]) AddContext(ctx context.Context, x ...T) error {
+	if s == nil {
+		return ErrNilSlice
+	}
+
+	if err := s.lock.LockContext(ctx); err != nil {
+		return err
+	}
+	events := s.add(x)
+	s.lock.Unlock()
+
+	s.publish(events)
+	return nil
+}
+
+// add appends items, lock must be held
+func (s *Slice[T]) add(x []T) (events []types.WatchMsg[int, T]) {
+	for i, v := range x {
+		events = s.event(events, types.InsertEvent, len(s.data)+i, v)
+	}
 	s.data = append(s.data, x...)
+	return events
 }
 
-func (s *Slice[T]) GetAll() []T {
+// InsertAt inserts items before idx, idx equal to Len appends.
+// Returns false if idx is out of range.
+func (s *Slice[T]) InsertAt(idx int, x ...T) bool {
+	return s.InsertAtE(idx, x...) == nil
+}
+
+// InsertAtE inserts items before idx, returns ErrOutOfRange if idx is out of range
+func (s *Slice[T]) InsertAtE(idx int, x ...T) error {
+	if s == nil {
+		return ErrNilSlice
+	}
+
 	s.lock.Lock()
-	defer s.lock.Unlock()
-	return s.data
+	events, err := s.insertAt(idx, x)
+	s.lock.Unlock()
+
+	s.publish(events)
+	return err
 }
 
-func (s *Slice[T]) Len() int {
+// InsertAtContext inserts items before idx, returns ErrOutOfRange if idx is out of range
+// and ctx error if lock is not acquired before ctx is done
+func (s *Slice[T]) InsertAtContext(ctx context.Context, idx int, x ...T) error {
+	if s == nil {
+		return ErrNilSlice
+	}
+
+	if err := s.lock.LockContext(ctx); err != nil {
+		return err
+	}
+	events, err := s.insertAt(idx, x)
+	s.lock.Unlock()
+
+	s.publish(events)
+	return err
+}
+
+// insertAt inserts items before idx, lock must be held
+func (s *Slice[T]) insertAt(idx int, x []T) (events []types.WatchMsg[int, T], err error) {
+	if idx < 0 || idx > len(s.data) {
+		return nil, ErrOutOfRange
+	}
+
+	data := make([]T, 0, len(s.data)+len(x))
+	data = append(data, s.data[:idx]...)
+	data = append(data, x...)
+	s.data = append(data, s.data[idx:]...)
+
+	for i, v := range x {
+		events = s.event(events, types.InsertEvent, idx+i, v)
+	}
+	return events, nil
+}
+
+// RemoveAt removes item at idx keeping order, returns false if idx is out of range
+func (s *Slice[T]) RemoveAt(idx int) (v T, ok bool) {
+	v, err := s.RemoveAtE(idx)
+	return v, err == nil
+}
+
+// RemoveAtE removes item at idx keeping order, returns ErrOutOfRange if idx is out of range
+func (s *Slice[T]) RemoveAtE(idx int) (v T, err error) {
+	if s == nil {
+		return v, ErrNilSlice
+	}
+
 	s.lock.Lock()
-	defer s.lock.Unlock()
+	v, events, err := s.removeAt(idx)
+	s.lock.Unlock()
+
+	s.publish(events)
+	return v, err
+}
+
+// RemoveAtContext removes item at idx keeping order, returns ErrOutOfRange if idx is out of range
+// and ctx error if lock is not acquired before ctx is done
+func (s *Slice[T]) RemoveAtContext(ctx context.Context, idx int) (v T, err error) {
+	if s == nil {
+		return v, ErrNilSlice
+	}
+
+	if err := s.lock.LockContext(ctx); err != nil {
+		return v, err
+	}
+	v, events, err := s.removeAt(idx)
+	s.lock.Unlock()
+
+	s.publish(events)
+	return v, err
+}
+
+// removeAt removes item at idx, lock must be held
+func (s *Slice[T]) removeAt(idx int) (v T, events []types.WatchMsg[int, T], err error) {
+	if idx < 0 || idx >= len(s.data) {
+		return v, nil, ErrOutOfRange
+	}
+
+	v = s.data[idx]
+	copy(s.data[idx:], s.data[idx+1:])
+	s.data[len(s.data)-1] = *new(T)
+	s.data = s.data[:len(s.data)-1]
+
+	events = s.event(events, types.RemoveEvent, idx, v)
+	return v, events, nil
+}
+
+// Set replaces item at idx, returns false if idx is out of range
+func (s *Slice[T]) Set(idx int, v T) bool {
+	return s.SetE(idx, v) == nil
+}
+
+// SetE replaces item at idx, returns ErrOutOfRange if idx is out of range
+func (s *Slice[T]) SetE(idx int, v T) error {
+	if s == nil {
+		return ErrNilSlice
+	}
+
+	s.lock.Lock()
+	events, err := s.set(idx, v)
+	s.lock.Unlock()
+
+	s.publish(events)
+	return err
+}
+
+// SetContext replaces item at idx, returns ErrOutOfRange if idx is out of range
+// and ctx error if lock is not acquired before ctx is done
+func (s *Slice[T]) SetContext(ctx context.Context, idx int, v T) error {
+	if s == nil {
+		return ErrNilSlice
+	}
+
+	if err := s.lock.LockContext(ctx); err != nil {
+		return err
+	}
+	events, err := s.set(idx, v)
+	s.lock.Unlock()
+
+	s.publish(events)
+	return err
+}
+
+// set replaces item at idx, lock must be held
+func (s *Slice[T]) set(idx int, v T) (events []types.WatchMsg[int, T], err error) {
+	if idx < 0 || idx >= len(s.data) {
+		return nil, ErrOutOfRange
+	}
+
+	events = s.event(events, types.RemoveEvent, idx, s.data[idx])
+	events = s.event(events, types.InsertEvent, idx, v)
+	s.data[idx] = v
+	return events, nil
+}
+
+// GetAll returns copy of all items
+func (s *Slice[T]) GetAll() []T {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	out := make([]T, len(s.data))
+	copy(out, s.data)
+	return out
+}
+
+func (s *Slice[T]) Len() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	return len(s.data)
 }
 
+// LockStats returns lock contention stats, collected only in lock debug mode
+func (s *Slice[T]) LockStats() utils.LockStats {
+	if s == nil {
+		return utils.LockStats{}
+	}
+	return s.lock.Stats()
+}
+
 func (s *Slice[T]) Clear() {
+	s.Take()
+}
+
+// ClearContext removes all items, returns ctx error if lock is not acquired before ctx is done
+func (s *Slice[T]) ClearContext(ctx context.Context) error {
+	_, err := s.TakeContext(ctx)
+	return err
+}
+
+// Get returns copy of item at idx, nil if idx is out of range
+func (s *Slice[T]) Get(idx int) *T {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if idx < 0 || idx >= len(s.data) {
+		return nil
+	}
+
+	v := s.data[idx]
+	return &v
+}
+
+// IndexOf returns index of first item for which fn returns true, -1 if none
+func (s *Slice[T]) IndexOf(fn func(v T) bool) int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for i, v := range s.data {
+		if fn(v) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Find returns first item for which fn returns true
+func (s *Slice[T]) Find(fn func(v T) bool) (v T, ok bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for _, v := range s.data {
+		if fn(v) {
+			return v, true
+		}
+	}
+	return
+}
+
+// Sort sorts items with stable sort
+func (s *Slice[T]) Sort(less func(a, b T) bool) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	s.data = make([]T, 0, s.capacity)
+
+	s.sort(less)
 }
 
-func (s *Slice[T]) Get(idx int) *T {
+// SortContext sorts items with stable sort, returns ctx error if lock is not acquired before ctx is done
+func (s *Slice[T]) SortContext(ctx context.Context, less func(a, b T) bool) error {
+	if s == nil {
+		return ErrNilSlice
+	}
+
+	if err := s.lock.LockContext(ctx); err != nil {
+		return err
+	}
+	defer s.lock.Unlock()
+
+	s.sort(less)
+	return nil
+}
+
+// sort sorts items, lock must be held
+func (s *Slice[T]) sort(less func(a, b T) bool) {
+	sort.SliceStable(s.data, func(i, j int) bool {
+		return less(s.data[i], s.data[j])
+	})
+}
+
+// BinarySearch searches sorted slice, cmp returns negative value if item is before the target,
+// zero if it is the target and positive value if it is after.
+// Returns index where target is or would be inserted, and whether it was found.
+func (s *Slice[T]) BinarySearch(cmp func(v T) int) (int, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	idx := sort.Search(len(s.data), func(i int) bool {
+		return cmp(s.data[i]) >= 0
+	})
+	return idx, idx < len(s.data) && cmp(s.data[idx]) == 0
+}
+
+// Filter keeps only items for which fn returns true
+func (s *Slice[T]) Filter(fn func(v T) bool) {
+	s.lock.Lock()
+	events := s.filter(fn)
+	s.lock.Unlock()
+
+	s.publishReversed(events)
+}
+
+// FilterContext keeps only items for which fn returns true,
+// returns ctx error if lock is not acquired before ctx is done
+func (s *Slice[T]) FilterContext(ctx context.Context, fn func(v T) bool) error {
+	if s == nil {
+		return ErrNilSlice
+	}
+
+	if err := s.lock.LockContext(ctx); err != nil {
+		return err
+	}
+	events := s.filter(fn)
+	s.lock.Unlock()
+
+	s.publishReversed(events)
+	return nil
+}
+
+// filter keeps items for which fn returns true, lock must be held
+func (s *Slice[T]) filter(fn func(v T) bool) (events []types.WatchMsg[int, T]) {
+	kept := 0
+	for i, v := range s.data {
+		if fn(v) {
+			s.data[kept] = v
+			kept++
+			continue
+		}
+		events = s.event(events, types.RemoveEvent, i, v)
+	}
+	s.truncate(kept)
+	return events
+}
+
+// Dedupe removes duplicated items keeping the first occurrence
+func (s *Slice[T]) Dedupe(equal func(a, b T) bool) {
+	s.lock.Lock()
+	events := s.dedupe(equal)
+	s.lock.Unlock()
+
+	s.publishReversed(events)
+}
+
+// DedupeContext removes duplicated items keeping the first occurrence,
+// returns ctx error if lock is not acquired before ctx is done
+func (s *Slice[T]) DedupeContext(ctx context.Context, equal func(a, b T) bool) error {
+	if s == nil {
+		return ErrNilSlice
+	}
+
+	if err := s.lock.LockContext(ctx); err != nil {
+		return err
+	}
+	events := s.dedupe(equal)
+	s.lock.Unlock()
+
+	s.publishReversed(events)
+	return nil
+}
+
+// dedupe removes duplicated items, lock must be held
+func (s *Slice[T]) dedupe(equal func(a, b T) bool) (events []types.WatchMsg[int, T]) {
+	kept := 0
+	for i, v := range s.data {
+		duplicate := false
+		for _, k := range s.data[:kept] {
+			if equal(k, v) {
+				duplicate = true
+				break
+			}
+		}
+
+		if duplicate {
+			events = s.event(events, types.RemoveEvent, i, v)
+			continue
+		}
+		s.data[kept] = v
+		kept++
+	}
+	s.truncate(kept)
+	return events
+}
+
+// truncate clears items after n, lock must be held
+func (s *Slice[T]) truncate(n int) {
+	var zero T
+	for i := n; i < len(s.data); i++ {
+		s.data[i] = zero
+	}
+	s.data = s.data[:n]
+}
+
+func (s *Slice[T]) Reverse() {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	if idx < 0 || idx >= len(s.data) {
+	s.reverse()
+}
+
+// ReverseContext reverses items, returns ctx error if lock is not acquired before ctx is done
+func (s *Slice[T]) ReverseContext(ctx context.Context) error {
+	if s == nil {
+		return ErrNilSlice
+	}
+
+	if err := s.lock.LockContext(ctx); err != nil {
+		return err
+	}
+	defer s.lock.Unlock()
+
+	s.reverse()
+	return nil
+}
+
+// reverse reverses items, lock must be held
+func (s *Slice[T]) reverse() {
+	for i, j := 0, len(s.data)-1; i < j; i, j = i+1, j-1 {
+		s.data[i], s.data[j] = s.data[j], s.data[i]
+	}
+}
+
+// Chunk returns copy of items split into parts of size, last part may be shorter
+func (s *Slice[T]) Chunk(size int) [][]T {
+	if size <= 0 {
 		return nil
 	}
 
-	return &s.data[idx]
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	chunks := make([][]T, 0, (len(s.data)+size-1)/size)
+	for i := 0; i < len(s.data); i += size {
+		end := i + size
+		if end > len(s.data) {
+			end = len(s.data)
+		}
+
+		chunk := make([]T, end-i)
+		copy(chunk, s.data[i:end])
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// ForEach calls fn for every item, fn must not modify the slice
+func (s *Slice[T]) ForEach(fn func(idx int, v T)) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for i, v := range s.data {
+		fn(i, v)
+	}
 }
 
+// Commit runs fn with direct access to data, no events are published
 func (s *Slice[T]) Commit(fn func(data *[]T, capacity int)) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	fn(&s.data, s.capacity)
 }
 
+// CommitContext runs fn with direct access to data, no events are published.
+// Returns ctx error if lock is not acquired before ctx is done.
+func (s *Slice[T]) CommitContext(ctx context.Context, fn func(data *[]T, capacity int)) error {
+	if s == nil {
+		return ErrNilSlice
+	}
+
+	if err := s.lock.LockContext(ctx); err != nil {
+		return err
+	}
+	defer s.lock.Unlock()
+
+	fn(&s.data, s.capacity)
+	return nil
+}
+
 func (s *Slice[T]) Take() []T {
 	s.lock.Lock()
-	defer s.lock.Unlock()
-	v := s.data
-	s.data = make([]T, 0, s.capacity)
+	v, events := s.take()
+	s.lock.Unlock()
+
+	s.publishReversed(events)
 	return v
 }
 
+// TakeContext returns all items and clears slice, returns ctx error if lock is not acquired before ctx is done
+func (s *Slice[T]) TakeContext(ctx context.Context) ([]T, error) {
+	if s == nil {
+		return nil, ErrNilSlice
+	}
+
+	if err := s.lock.LockContext(ctx); err != nil {
+		return nil, err
+	}
+	v, events := s.take()
+	s.lock.Unlock()
+
+	s.publishReversed(events)
+	return v, nil
+}
+
+// take clears slice, lock must be held
+func (s *Slice[T]) take() (v []T, events []types.WatchMsg[int, T]) {
+	v = s.data
+	s.data = make([]T, 0, s.capacity)
+	for i, x := range v {
+		events = s.event(events, types.RemoveEvent, i, x)
+	}
+	return v, events
+}
+
 func (s *Slice[T]) marshal(m types.MarshalFunc) ([]byte, error) {
 	if s == nil {
 		return nil, types.ErrNilSet
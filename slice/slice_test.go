@@ -0,0 +1,179 @@
+package slice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/timoni-io/go-utils/types"
+)
+
+func TestSliceCopyOnRead(t *testing.T) {
+	s := NewSafeSlice[int](0)
+	s.Add(1, 2, 3)
+
+	all := s.GetAll()
+	all[0] = 10
+	*s.Get(1) = 20
+
+	if !Equal(s.GetAll(), []int{1, 2, 3}) {
+		t.Errorf("internal data modified %v", s.GetAll())
+	}
+}
+
+func TestSliceInsertRemove(t *testing.T) {
+	s := NewSlice[int](0)
+	s.Add(1, 4)
+
+	if !s.InsertAt(1, 2, 3) || s.InsertAt(5, 0) {
+		t.Error("invalid insert result")
+	}
+	if v, ok := s.RemoveAt(0); !ok || v != 1 {
+		t.Error("invalid remove result")
+	}
+	if _, ok := s.RemoveAt(3); ok {
+		t.Error("removed out of range")
+	}
+	if !Equal(s.GetAll(), []int{2, 3, 4}) {
+		t.Errorf("invalid items %v", s.GetAll())
+	}
+	if s.IndexOf(func(v int) bool { return v == 3 }) != 1 {
+		t.Fail()
+	}
+}
+
+func TestSliceSortSearch(t *testing.T) {
+	s := NewSlice[int](0)
+	s.Add(5, 1, 4, 1, 3)
+
+	s.Dedupe(func(a, b int) bool { return a == b })
+	s.Sort(func(a, b int) bool { return a < b })
+
+	if !Equal(s.GetAll(), []int{1, 3, 4, 5}) {
+		t.Errorf("invalid items %v", s.GetAll())
+	}
+
+	idx, found := s.BinarySearch(func(v int) int { return v - 4 })
+	if idx != 2 || !found {
+		t.Errorf("invalid search result %d %v", idx, found)
+	}
+	idx, found = s.BinarySearch(func(v int) int { return v - 2 })
+	if idx != 1 || found {
+		t.Errorf("invalid search result %d %v", idx, found)
+	}
+
+	s.Filter(func(v int) bool { return v%2 == 1 })
+	s.Reverse()
+	if !Equal(s.GetAll(), []int{5, 3, 1}) {
+		t.Errorf("invalid items %v", s.GetAll())
+	}
+
+	chunks := s.Chunk(2)
+	if len(chunks) != 2 || len(chunks[1]) != 1 {
+		t.Errorf("invalid chunks %v", chunks)
+	}
+}
+
+func TestSliceEventfull(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := NewSafeSlice[int](0).Eventfull(ctx, 10)
+	defer s.Close()
+
+	events := s.Register(ctx)
+	s.Add(1, 2)
+	s.RemoveAt(0)
+
+	expected := []types.WatchMsg[int, int]{
+		{Event: types.InsertEvent, Item: types.Item[int, int]{Key: 0, Value: 1}},
+		{Event: types.InsertEvent, Item: types.Item[int, int]{Key: 1, Value: 2}},
+		{Event: types.RemoveEvent, Item: types.Item[int, int]{Key: 0, Value: 1}},
+	}
+	for _, e := range expected {
+		if msg := <-events; msg != e {
+			t.Errorf("expected %v, got %v", e, msg)
+		}
+	}
+}
+
+func TestSliceContext(t *testing.T) {
+	ctx := context.Background()
+	s := NewSafeSlice[int](0)
+
+	if err := s.AddContext(ctx, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertAtContext(ctx, 5, 3); err != ErrOutOfRange {
+		t.Errorf("expected ErrOutOfRange, got %v", err)
+	}
+	if v, err := s.RemoveAtContext(ctx, 0); v != 1 || err != nil {
+		t.Errorf("unexpected result %v %v", v, err)
+	}
+
+	s.lock.Lock()
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := s.SetContext(tctx, 0, 5); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	s.lock.Unlock()
+
+	if v, err := s.TakeContext(ctx); err != nil || !Equal(v, []int{2}) {
+		t.Errorf("unexpected result %v %v", v, err)
+	}
+
+	s.Add(3, 1, 3, 2)
+	if err := s.DedupeContext(ctx, func(a, b int) bool { return a == b }); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SortContext(ctx, func(a, b int) bool { return a < b }); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReverseContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.FilterContext(ctx, func(v int) bool { return v > 1 }); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CommitContext(ctx, func(data *[]int, _ int) { *data = append(*data, 0) }); err != nil {
+		t.Fatal(err)
+	}
+	if v := s.GetAll(); !Equal(v, []int{3, 2, 0}) {
+		t.Errorf("unexpected result %v", v)
+	}
+	if err := s.ClearContext(ctx); err != nil || s.Len() != 0 {
+		t.Errorf("unexpected result %v %v", s.Len(), err)
+	}
+
+}
+
+func TestSliceNil(t *testing.T) {
+	ctx := context.Background()
+	var s *Slice[int]
+
+	if err := s.AddE(1); err != ErrNilSlice {
+		t.Errorf("expected ErrNilSlice, got %v", err)
+	}
+	if err := s.InsertAtE(0, 1); err != ErrNilSlice {
+		t.Errorf("expected ErrNilSlice, got %v", err)
+	}
+	if _, err := s.RemoveAtE(0); err != ErrNilSlice {
+		t.Errorf("expected ErrNilSlice, got %v", err)
+	}
+	if err := s.SetE(0, 1); err != ErrNilSlice {
+		t.Errorf("expected ErrNilSlice, got %v", err)
+	}
+	if err := s.InsertAtContext(ctx, 0, 1); err != ErrNilSlice {
+		t.Errorf("expected ErrNilSlice, got %v", err)
+	}
+	if _, err := s.RemoveAtContext(ctx, 0); err != ErrNilSlice {
+		t.Errorf("expected ErrNilSlice, got %v", err)
+	}
+	if err := s.SetContext(ctx, 0, 1); err != ErrNilSlice {
+		t.Errorf("expected ErrNilSlice, got %v", err)
+	}
+	if err := s.ClearContext(ctx); err != ErrNilSlice {
+		t.Errorf("expected ErrNilSlice, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package slice
+
+import (
+	"context"
+	"sync/atomic"
+
+	"golang.org/x/exp/constraints"
+)
+
+// SPSCRigid is a lock-free circular buffer for single producer and single consumer.
+// Push must be called from one goroutine and Pop/TryPop from one other goroutine.
+// Items are never overwritten, Push rejects items when buffer is full.
+type SPSCRigid[T any, S constraints.Unsigned] struct {
+	data []T
+
+	// head is written only by consumer, tail only by producer
+	head atomic.Uint64
+	tail atomic.Uint64
+
+	notify chan struct{}
+}
+
+func NewSPSCRigid[T any, S constraints.Unsigned](size S) *SPSCRigid[T, S] {
+	if size == 0 {
+		size = 1
+	}
+
+	return &SPSCRigid[T, S]{
+		data:   make([]T, size),
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// Push adds item, returns false if buffer is full
+func (r *SPSCRigid[T, S]) Push(v T) bool {
+	tail := r.tail.Load()
+	if tail-r.head.Load() == uint64(len(r.data)) {
+		return false
+	}
+
+	r.data[tail%uint64(len(r.data))] = v
+	r.tail.Store(tail + 1)
+
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// TryPop removes and returns the oldest item, returns false if buffer is empty
+func (r *SPSCRigid[T, S]) TryPop() (v T, ok bool) {
+	head := r.head.Load()
+	if head == r.tail.Load() {
+		return
+	}
+
+	var zero T
+	idx := head % uint64(len(r.data))
+	v = r.data[idx]
+	r.data[idx] = zero
+	r.head.Store(head + 1)
+	return v, true
+}
+
+// Pop removes and returns the oldest item, waits until an item is available or ctx is done
+func (r *SPSCRigid[T, S]) Pop(ctx context.Context) (T, error) {
+	for {
+		if v, ok := r.TryPop(); ok {
+			return v, nil
+		}
+
+		select {
+		case <-r.notify:
+		case <-ctx.Done():
+			return *new(T), ctx.Err()
+		}
+	}
+}
+
+// Len returns number of items, may be stale when called concurrently
+func (r *SPSCRigid[T, S]) Len() int {
+	head := r.head.Load()
+	return int(r.tail.Load() - head)
+}
+
+func (r *SPSCRigid[T, S]) Cap() int {
+	return len(r.data)
+}
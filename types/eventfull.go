@@ -11,4 +11,6 @@ type EventType string
 const (
 	PutEvent    = "PUT"
 	DeleteEvent = "DELETE"
+	InsertEvent = "INSERT"
+	RemoveEvent = "REMOVE"
 )
@@ -1,11 +1,11 @@
 package types
 
-import "errors"
+import "github.com/timoni-io/go-utils/errors"
 
 var (
-	ErrNilSet      = errors.New("set is nil")
-	ErrNilMap      = errors.New("map is nil")
-	ErrReadOnlyMap = errors.New("map is readonly")
+	ErrNilSet      = errors.New(errors.Internal, "set is nil")
+	ErrNilMap      = errors.New(errors.Internal, "map is nil")
+	ErrReadOnlyMap = errors.New(errors.FailedPrecondition, "map is readonly")
 )
 
 type Iterator[K comparable, V any] <-chan Item[K, V]
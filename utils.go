@@ -3,7 +3,6 @@ package utils
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"os"
 	"sync"
 	"time"
@@ -14,22 +13,34 @@ func PathExists(path string) bool {
 	return !os.IsNotExist(err)
 }
 
+// DeepCopy returns copy of src made through JSON, nil if src can't be copied
 func DeepCopy[T any](src T) *T {
+	dst, _ := DeepCopyE(src)
+	return dst
+}
+
+// DeepCopyE returns copy of src made through JSON, or error if src can't be marshaled
+func DeepCopyE[T any](src T) (*T, error) {
 	data, err := json.Marshal(src)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	dst := new(T)
 	err = json.Unmarshal(data, dst)
 	if err != nil {
-		return nil
+		return nil, err
 	}
-	return dst
+	return dst, nil
 }
 
+// ErrTimeout is returned when waiting timed out
+var ErrTimeout = errors.New("timeout")
+
 // WaitWithTimeout waits for the waitgroup for the specified max timeout.
-// Returns error if waiting timed out.
+// Returns ErrTimeout if waiting timed out.
+// WaitGroup can't be waited with cancellation, so the helper goroutine lives until wg is done,
+// use Group to wait for tasks without it.
 func WaitWithTimeout(wg *sync.WaitGroup, timeout time.Duration) error {
 	c := make(chan struct{})
 	go func() {
@@ -37,17 +48,23 @@ func WaitWithTimeout(wg *sync.WaitGroup, timeout time.Duration) error {
 		close(c)
 	}()
 
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
 	select {
 	case <-c:
 		return nil
-	case <-time.After(timeout):
-		return errors.New("timeout")
+	case <-t.C:
+		return ErrTimeout
 	}
 }
 
+// PanicHandler recovers panic when deferred directly and reports it to panic hook.
+//
+// Deprecated: returned error is lost when deferred, use Recover.
 func PanicHandler() error {
-	if err := recover(); err != nil {
-		return fmt.Errorf("%v", err)
+	if r := recover(); r != nil {
+		return CapturePanic(r)
 	}
 
 	return nil
@@ -0,0 +1,342 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/timoni-io/go-utils"
+	"github.com/timoni-io/go-utils/channel"
+	"github.com/timoni-io/go-utils/metrics"
+)
+
+var ErrClosed = errors.New("pool is closed")
+
+// Job is a single unit of work
+type Job[T any] func(ctx context.Context) (T, error)
+
+type RetryPolicy struct {
+	// number of retries after the first failure
+	Attempts int
+	// delay before retry, attempt starts from 1
+	Backoff func(attempt int) time.Duration
+	// decides if error is worth retrying, all errors are retried if nil
+	Retryable func(err error) bool
+}
+
+type Options struct {
+	// number of always running workers, NumCPU if <= 0
+	Workers int
+	// upper limit of autoscaling, pool has fixed size if MaxWorkers <= Workers
+	MaxWorkers int
+	// extra workers exit after being idle for IdleTimeout, 10s if <= 0
+	IdleTimeout time.Duration
+	// number of jobs waiting for a worker, Submit blocks when queue is full
+	Queue int
+	// timeout of single job attempt, no timeout if <= 0
+	Timeout time.Duration
+	Retry   RetryPolicy
+}
+
+type Metrics struct {
+	Queued    metrics.Value
+	Running   metrics.Value
+	Workers   metrics.Value
+	Completed metrics.Value
+	Failed    metrics.Value
+	Retried   metrics.Value
+}
+
+type task[T any] struct {
+	ctx     context.Context
+	job     Job[T]
+	promise *channel.Promise[T]
+}
+
+// Pool runs submitted jobs with a limited number of workers
+type Pool[T any] struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	opts   Options
+	tasks  chan task[T]
+	wg     sync.WaitGroup
+
+	// guards closed and tasks channel closing
+	closeLock sync.RWMutex
+	closed    bool
+	// closed before taking closeLock, so Submit blocked on full queue releases it
+	stopping chan struct{}
+	stopOnce sync.Once
+
+	// guards workers and idle
+	lock    sync.Mutex
+	workers int
+	idle    int
+
+	metrics Metrics
+}
+
+// New creates pool, running jobs are canceled when ctx is done
+func New[T any](ctx context.Context, opts Options) *Pool[T] {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.MaxWorkers < opts.Workers {
+		opts.MaxWorkers = opts.Workers
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = 10 * time.Second
+	}
+
+	p := &Pool[T]{
+		opts:     opts,
+		tasks:    make(chan task[T], opts.Queue),
+		stopping: make(chan struct{}),
+	}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+
+	p.lock.Lock()
+	for i := 0; i < opts.Workers; i++ {
+		p.spawn(false)
+	}
+	p.lock.Unlock()
+
+	return p
+}
+
+// spawn starts new worker, lock must be held
+func (p *Pool[T]) spawn(extra bool) {
+	p.workers++
+	p.metrics.Workers.Add(1)
+	p.wg.Add(1)
+	go p.worker(extra)
+}
+
+func (p *Pool[T]) worker(extra bool) {
+	defer p.wg.Done()
+
+	// extra workers exit when idle for too long
+	var timeout <-chan time.Time
+	var timer *time.Timer
+	if extra {
+		timer = time.NewTimer(p.opts.IdleTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		p.lock.Lock()
+		p.idle++
+		p.lock.Unlock()
+
+		select {
+		case t, ok := <-p.tasks:
+			p.lock.Lock()
+			p.idle--
+			if !ok {
+				p.workers--
+				p.metrics.Workers.Add(-1)
+			}
+			p.lock.Unlock()
+
+			if !ok {
+				return
+			}
+			p.run(t)
+
+			if extra {
+				stopTimer(timer)
+				timer.Reset(p.opts.IdleTimeout)
+			}
+
+		case <-timeout:
+			p.lock.Lock()
+			p.idle--
+			p.workers--
+			p.metrics.Workers.Add(-1)
+			p.lock.Unlock()
+			return
+		}
+	}
+}
+
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
+
+func (p *Pool[T]) run(t task[T]) {
+	p.metrics.Queued.Add(-1)
+
+	if p.ctx.Err() != nil {
+		t.promise.Reject(ErrClosed)
+		p.metrics.Failed.Add(1)
+		return
+	}
+	if err := t.ctx.Err(); err != nil {
+		t.promise.Reject(err)
+		p.metrics.Failed.Add(1)
+		return
+	}
+
+	p.metrics.Running.Add(1)
+	defer p.metrics.Running.Add(-1)
+
+	// job is canceled by its own ctx or when pool is closed
+	ctx, cancel := context.WithCancel(t.ctx)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-p.ctx.Done():
+			cancel()
+		case <-stop:
+		}
+	}()
+
+	v, err := p.retry(ctx, t.job)
+	close(stop)
+	cancel()
+
+	if err != nil {
+		p.metrics.Failed.Add(1)
+	} else {
+		p.metrics.Completed.Add(1)
+	}
+	t.promise.Complete(v, err)
+}
+
+// retry runs job until it succeeds, retries are exhausted or ctx is done
+func (p *Pool[T]) retry(ctx context.Context, job Job[T]) (v T, err error) {
+	for attempt := 0; ; attempt++ {
+		v, err = p.attempt(ctx, job)
+		if err == nil || attempt >= p.opts.Retry.Attempts || ctx.Err() != nil {
+			return v, err
+		}
+		if p.opts.Retry.Retryable != nil && !p.opts.Retry.Retryable(err) {
+			return v, err
+		}
+
+		p.metrics.Retried.Add(1)
+		if p.opts.Retry.Backoff == nil {
+			continue
+		}
+
+		t := time.NewTimer(p.opts.Retry.Backoff(attempt + 1))
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return v, err
+		}
+	}
+}
+
+// attempt runs job once with timeout, panic is returned as error
+func (p *Pool[T]) attempt(ctx context.Context, job Job[T]) (v T, err error) {
+	if p.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.opts.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = utils.CapturePanic(r)
+		}
+	}()
+	return job(ctx)
+}
+
+// Submit queues job and returns its future, it blocks while queue is full.
+// Job ctx is canceled when ctx is done or pool is closed.
+// Future fails with ErrClosed if pool is closed before job starts.
+func (p *Pool[T]) Submit(ctx context.Context, job Job[T]) *channel.Future[T] {
+	promise := channel.NewPromise[T]()
+
+	p.closeLock.RLock()
+	defer p.closeLock.RUnlock()
+
+	if p.closed {
+		promise.Reject(ErrClosed)
+		return promise.Future()
+	}
+
+	p.metrics.Queued.Add(1)
+	select {
+	case p.tasks <- task[T]{ctx: ctx, job: job, promise: promise}:
+	case <-ctx.Done():
+		p.metrics.Queued.Add(-1)
+		promise.Reject(ctx.Err())
+		return promise.Future()
+	case <-p.ctx.Done():
+		p.metrics.Queued.Add(-1)
+		promise.Reject(ErrClosed)
+		return promise.Future()
+	case <-p.stopping:
+		p.metrics.Queued.Add(-1)
+		promise.Reject(ErrClosed)
+		return promise.Future()
+	}
+
+	p.lock.Lock()
+	if p.idle == 0 && p.workers < p.opts.MaxWorkers {
+		p.spawn(true)
+	}
+	p.lock.Unlock()
+
+	return promise.Future()
+}
+
+// Metrics returns live pool metrics
+func (p *Pool[T]) Metrics() *Metrics {
+	return &p.metrics
+}
+
+// stop stops accepting new jobs, workers exit after queue is drained
+func (p *Pool[T]) stop() {
+	p.stopOnce.Do(func() { close(p.stopping) })
+
+	p.closeLock.Lock()
+	defer p.closeLock.Unlock()
+
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+}
+
+// Shutdown stops accepting jobs and waits until queued and running jobs are finished.
+// When ctx is done first, running jobs are canceled, queued ones fail with ErrClosed
+// and ctx error is returned.
+func (p *Pool[T]) Shutdown(ctx context.Context) error {
+	p.stop()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Close cancels running jobs, fails queued ones with ErrClosed and waits for workers
+func (p *Pool[T]) Close() {
+	p.cancel()
+	p.stop()
+	p.wg.Wait()
+}
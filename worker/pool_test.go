@@ -0,0 +1,203 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/timoni-io/go-utils/channel"
+)
+
+func TestPoolSubmit(t *testing.T) {
+	ctx := context.Background()
+	p := New[int](ctx, Options{Workers: 2, Queue: 10})
+	defer p.Close()
+
+	fs := []*channel.Future[int]{}
+	for i := 0; i < 5; i++ {
+		i := i
+		fs = append(fs, p.Submit(ctx, func(context.Context) (int, error) {
+			return i * i, nil
+		}))
+	}
+
+	for i, f := range fs {
+		if v, err := f.Await(ctx); v != i*i || err != nil {
+			t.Errorf("unexpected result %v %v", v, err)
+		}
+	}
+	if p.Metrics().Completed.Load() != 5 || p.Metrics().Queued.Load() != 0 {
+		t.Errorf("unexpected metrics %v %v", &p.Metrics().Completed, &p.Metrics().Queued)
+	}
+}
+
+func TestPoolRetry(t *testing.T) {
+	ctx := context.Background()
+	p := New[int](ctx, Options{Workers: 1, Retry: RetryPolicy{
+		Attempts: 2,
+		Backoff:  func(int) time.Duration { return time.Millisecond },
+	}})
+	defer p.Close()
+
+	calls := 0
+	v, err := p.Submit(ctx, func(context.Context) (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, errors.New("fail")
+		}
+		return calls, nil
+	}).Await(ctx)
+	if v != 3 || err != nil {
+		t.Errorf("unexpected result %v %v", v, err)
+	}
+
+	_, err = p.Submit(ctx, func(context.Context) (int, error) {
+		return 0, errors.New("fail")
+	}).Await(ctx)
+	if err == nil || p.Metrics().Retried.Load() != 4 || p.Metrics().Failed.Load() != 1 {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestPoolTimeoutPanic(t *testing.T) {
+	ctx := context.Background()
+	p := New[int](ctx, Options{Workers: 1, Timeout: 10 * time.Millisecond})
+	defer p.Close()
+
+	_, err := p.Submit(ctx, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}).Await(ctx)
+	if err != context.DeadlineExceeded {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	_, err = p.Submit(ctx, func(context.Context) (int, error) {
+		panic("boom")
+	}).Await(ctx)
+	if err == nil || err.Error() != "panic: boom" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestPoolAutoscale(t *testing.T) {
+	ctx := context.Background()
+	p := New[int](ctx, Options{Workers: 1, MaxWorkers: 3, IdleTimeout: 10 * time.Millisecond, Queue: 10})
+	defer p.Close()
+
+	release := make(chan struct{})
+	fs := []*channel.Future[int]{}
+	for i := 0; i < 3; i++ {
+		fs = append(fs, p.Submit(ctx, func(context.Context) (int, error) {
+			<-release
+			return 0, nil
+		}))
+		if !waitFor(func() bool { return p.Metrics().Running.Load() == int64(i+1) }) {
+			t.Fatalf("expected %d running jobs, got %d", i+1, p.Metrics().Running.Load())
+		}
+	}
+	close(release)
+
+	for _, f := range fs {
+		f.Await(ctx)
+	}
+	if !waitFor(func() bool { return p.Metrics().Workers.Load() == 1 }) {
+		t.Fatalf("extra workers not stopped, %d workers", p.Metrics().Workers.Load())
+	}
+}
+
+func waitFor(fn func() bool) bool {
+	for i := 0; i < 200; i++ {
+		if fn() {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
+
+func TestPoolShutdown(t *testing.T) {
+	ctx := context.Background()
+	p := New[int](ctx, Options{Workers: 1, Queue: 10})
+
+	var done int32
+	fs := []*channel.Future[int]{}
+	for i := 0; i < 3; i++ {
+		fs = append(fs, p.Submit(ctx, func(context.Context) (int, error) {
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&done, 1)
+			return 0, nil
+		}))
+	}
+
+	if err := p.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&done) != 3 {
+		t.Error("queued jobs not drained")
+	}
+
+	if _, err := p.Submit(ctx, func(context.Context) (int, error) { return 0, nil }).Await(ctx); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestPoolShutdownBlockedSubmit(t *testing.T) {
+	ctx := context.Background()
+	p := New[int](ctx, Options{Workers: 1, Queue: 1})
+
+	job := func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	p.Submit(ctx, job)
+	if !waitFor(func() bool { return p.Metrics().Running.Load() == 1 }) {
+		t.Fatal("job not started")
+	}
+	p.Submit(ctx, job)
+
+	// queue is full, Submit blocks until Shutdown
+	submitted := make(chan *channel.Future[int])
+	go func() {
+		submitted <- p.Submit(ctx, job)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	sctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := p.Shutdown(sctx); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Shutdown took %v", d)
+	}
+	if _, err := (<-submitted).Await(ctx); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	ctx := context.Background()
+	p := New[int](ctx, Options{Workers: 1, Queue: 10})
+
+	started := make(chan struct{})
+	running := p.Submit(ctx, func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	queued := p.Submit(ctx, func(context.Context) (int, error) { return 1, nil })
+
+	<-started
+	p.Close()
+
+	if _, err := running.Await(ctx); err != context.Canceled {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := queued.Await(ctx); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}